@@ -0,0 +1,121 @@
+package cropgraph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// normalizeColumnName removes whitespace and case from a column name, e.g. "W suffic" and "Wsuffic" are equal
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// columnAliases returns the canonical column name of each normalized source name of Config.Aliases
+func (config Config) columnAliases() (map[string]string, error) {
+	aliases := map[string]string{}
+	for canonical, sourceNames := range config.Aliases {
+		for _, sourceName := range append([]string{canonical}, sourceNames...) {
+			normalized := normalizeColumnName(sourceName)
+			if other, ok := aliases[normalized]; ok && other != canonical {
+				return nil, fmt.Errorf("alias %q is used for the columns %q and %q", sourceName, other, canonical)
+			}
+			aliases[normalized] = canonical
+		}
+	}
+	return aliases, nil
+}
+
+// readAliasFile reads a mapping of canonical column names to source names,
+// a relative path is relative to the directory of the config file
+func readAliasFile(aliasFile, configFile string) (map[string][]string, error) {
+	aliases := map[string][]string{}
+	if err := readConfigRelativeYAML(aliasFile, configFile, &aliases); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// canonicalGraph renames the columns of the graph and its operations, which are aliases, to their canonical names,
+// including the columns of expressions, reset columns, the layer columns of paw and the resampled columns
+func canonicalGraph(graph GraphDefinition, aliases map[string]string) GraphDefinition {
+	canonicalName := func(name string) string {
+		if alias, ok := aliases[normalizeColumnName(name)]; ok && !isColumnPattern(name) {
+			return alias
+		}
+		return name
+	}
+	canonical := func(names []string) []string {
+		renamed := make([]string, len(names))
+		for i, name := range names {
+			renamed[i] = canonicalName(name)
+		}
+		return renamed
+	}
+	graph.Columns = canonical(graph.Columns)
+	if graph.DateColumn != "" {
+		graph.DateColumn = canonicalName(graph.DateColumn)
+	}
+	if graph.ColumnView != nil {
+		operations := make([]OperationDefinition, len(graph.ColumnView))
+		for i, operation := range graph.ColumnView {
+			operation.Columns = canonical(operation.Columns)
+			operation = canonicalParameters(operation, canonicalName)
+			operations[i] = operation
+		}
+		graph.ColumnView = operations
+	}
+	if graph.Resample != nil && graph.Resample.Columns != nil {
+		resample := *graph.Resample
+		resample.Columns = map[string]string{}
+		for name, aggregate := range graph.Resample.Columns {
+			resample.Columns[canonicalName(name)] = aggregate
+		}
+		graph.Resample = &resample
+	}
+	return graph
+}
+
+// canonicalParameters renames the columns in the parameters of the operation
+func canonicalParameters(operation OperationDefinition, canonicalName func(name string) string) OperationDefinition {
+	renamed := map[string]interface{}{}
+	if text := stringParameter(operation, "expression", ""); text != "" {
+		renamed["expression"] = renameExpressionColumns(text, canonicalName)
+	}
+	if name := stringParameter(operation, "resetcolumn", ""); name != "" {
+		renamed["resetcolumn"] = canonicalName(name)
+	}
+	for _, name := range []string{"fc", "wp"} {
+		if template := stringParameter(operation, name, ""); template != "" {
+			renamed[name] = canonicalLayerTemplate(template, canonicalName)
+		}
+	}
+	if len(renamed) == 0 {
+		return operation
+	}
+	parameters := make(map[string]interface{}, len(operation.Parameters))
+	for name, value := range operation.Parameters {
+		parameters[name] = value
+	}
+	for name, value := range renamed {
+		parameters[name] = value
+	}
+	operation.Parameters = parameters
+	return operation
+}
+
+// canonicalLayerTemplate renames a layer column template (e.g. FieldCap{n}), if the column of the first layer
+// is an alias of a column with the same layer number (e.g. FC 1)
+func canonicalLayerTemplate(template string, canonicalName func(name string) string) string {
+	if !strings.Contains(template, layerPlaceholder) {
+		return template
+	}
+	name := strings.ReplaceAll(template, layerPlaceholder, "1")
+	canonical := canonicalName(name)
+	if canonical == name || !strings.HasSuffix(canonical, "1") {
+		return template
+	}
+	if layer, ok := layerNumber(canonical); !ok || layer != 1 {
+		return template
+	}
+	return strings.TrimSuffix(canonical, "1") + layerPlaceholder
+}
@@ -0,0 +1,41 @@
+package cropgraph
+
+import "testing"
+
+func TestAliasesInOperationParameters(t *testing.T) {
+	config, err := ReadConfigFile(writeConfig(t, `
+aliases:
+  abovegrDryM: [AGB]
+  Stage: [DevStage]
+  FC 1: [FieldCap1]
+derived:
+  - {operation: expr, name: agbt, parameters: {expression: AGB / 1000}}
+columntograph:
+  g:
+    graphtype: line
+    title: g
+    columns: [AGB, DevStage, SoilW 1]
+    columnview:
+      - {operation: cumsum, name: cum, columns: [AGB], parameters: {resetcolumn: devstage}}
+      - {operation: paw, name: paw, columns: [SoilW 1], parameters: {fc: "FieldCap{n}", wp: "WP {n}"}}
+    resample: {period: month, columns: {AGB: last}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := config.ColumnToGraph["g"]
+	for _, test := range []struct {
+		found, want string
+	}{
+		{stringParameter(config.Derived[0], "expression", ""), `"abovegrDryM" / 1000`},
+		{graph.Columns[0], "abovegrDryM"},
+		{stringParameter(graph.ColumnView[0], "resetcolumn", ""), "Stage"},
+		{stringParameter(graph.ColumnView[1], "fc", ""), "FC {n}"},
+		{stringParameter(graph.ColumnView[1], "wp", ""), "WP {n}"},
+		{graph.Resample.Columns["abovegrDryM"], "last"},
+	} {
+		if test.found != test.want {
+			t.Errorf("found %q, want %q", test.found, test.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cropgraph
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// date format of apsim report files
+const apsimDateFormat = "02/01/2006"
+
+func init() {
+	RegisterDataSource("ApsimOut", apsimOutSource{})
+}
+
+// apsimOutSource reads apsim classic report files (.out)
+//
+// a report starts with a title block of "key = value" lines (e.g. ApsimVersion, Title),
+// followed by a line of column names and a line of units in parentheses,
+// the values are separated by whitespace.
+// Dates are written as dd/mm/yyyy, missing values as "?".
+type apsimOutSource struct{}
+
+func (apsimOutSource) Read(inputFile string, config Config) (*SourceData, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	configColumns := requiredColumns(config)
+	builder := newTableBuilder(inputFile, config, apsimDateFormat)
+	// apsim writes missing values as "?"
+	builder.AddNATokens("?")
+	for colName := range dateColumns(config) {
+		builder.SetDateFormat(colName, apsimDateFormat)
+	}
+
+	data := &SourceData{ColumnIndex: map[string]int{}}
+	// number of columns, 0 until the column names are read
+	numColumns := 0
+	unitsRead := false
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case numColumns == 0 && strings.Contains(line, "="):
+			// title block
+			continue
+		case numColumns == 0:
+			// column names
+			numColumns = len(fields)
+			for colIndex, colName := range fields {
+				if colName, ok := configColumns.Resolve(colName); ok {
+					data.ColumnIndex[colName] = colIndex
+					builder.AddColumn(colName)
+				}
+			}
+		case !unitsRead && strings.HasPrefix(line, "("):
+			// units line
+			unitsRead = true
+			for colName, colIndex := range data.ColumnIndex {
+				if colIndex >= len(fields) {
+					continue
+				}
+				unit := strings.TrimSuffix(strings.TrimPrefix(fields[colIndex], "("), ")")
+				if unit == "dd/mm/yyyy" {
+					builder.SetDateFormat(colName, apsimDateFormat)
+				} else if unit != "" {
+					builder.SetUnit(colName, unit)
+				}
+			}
+		default:
+			unitsRead = true
+			if len(fields) != numColumns {
+				return nil, &ParseError{File: inputFile, Line: lineNumber,
+					Err: fmt.Errorf("expected %d values, found %d", numColumns, len(fields))}
+			}
+			builder.StartRow(lineNumber)
+			for colName, colIndex := range data.ColumnIndex {
+				builder.Append(colName, fields[colIndex])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if numColumns == 0 {
+		return nil, fmt.Errorf("%s: no apsim column names found", inputFile)
+	}
+	data.Table, err = builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,33 @@
+package cropgraph
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApsimReader(t *testing.T) {
+	config := Config{InputType: "ApsimOut", ColumnToGraph: map[string]GraphDefinition{
+		"g": {GraphType: "line", Columns: []string{"Date", "biomass", "lai"}, DateColumn: "Date"}}}
+	data, err := ReadInputData("../test_data/apsim/Wheat.out", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, _ := data.Table.Column("Date")
+	biomass, _ := data.Table.Column("biomass")
+	lai, _ := data.Table.Column("lai")
+	if date == nil || biomass == nil || lai == nil {
+		t.Fatalf("found columns %v", data.Table.Names())
+	}
+	wantDates := []time.Time{time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if date.Type != TimeColumn || !slices.EqualFunc(date.Times, wantDates, time.Time.Equal) {
+		t.Errorf("found dates %v, want %v", date.Times, wantDates)
+	}
+	if !slices.Equal(biomass.Floats, []float64{1, 2.5, 4}) || biomass.Unit != "kg/ha" {
+		t.Errorf("found biomass %v [%s]", biomass.Floats, biomass.Unit)
+	}
+	// ? is missing and () is no unit
+	if !slices.Equal(lai.Valid, []bool{false, true, true}) || lai.Unit != "" {
+		t.Errorf("found valid lai %v [%s]", lai.Valid, lai.Unit)
+	}
+}
@@ -0,0 +1,176 @@
+package cropgraph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// placeholder of the layer number in the names of the field capacity and wilting point columns
+const layerPlaceholder = "{n}"
+
+// results of the paw operation
+const (
+	// plant-available water as share of the available water capacity
+	PAWFraction = "fraction"
+	// plant-available water in mm
+	PAWStorage = "mm"
+)
+
+// layerNumber returns the number at the end of a layer column, e.g. 3 of SoilW 3
+func layerNumber(name string) (int, bool) {
+	trimmed := strings.TrimRight(name, "0123456789")
+	number, err := strconv.Atoi(name[len(trimmed):])
+	return number, err == nil
+}
+
+// layerColumn returns the column of the name template for the layer, e.g. FC 3 for FC {n},
+// or of the nearest layer above, if the output has the column only for the first layer of each horizon
+func layerColumn(operationDefinition OperationDefinition, context OperationContext, template string, layer int) (*Column, error) {
+	for number := layer; number >= 0; number-- {
+		name := strings.ReplaceAll(template, layerPlaceholder, strconv.Itoa(number))
+		if column, ok := context.Columns[name]; ok {
+			return column, nil
+		}
+	}
+	return nil, fmt.Errorf("operation %s: no column %s for layer %d in the columns of the graph",
+		operationDefinition.Name, template, layer)
+}
+
+// validatePAW checks the parameters of the paw operation, which do not depend on the columns
+func validatePAW(operationDefinition OperationDefinition) error {
+	for _, name := range []string{"fc", "wp"} {
+		if template := stringParameter(operationDefinition, name, ""); template != "" && !strings.Contains(template, layerPlaceholder) {
+			return fmt.Errorf("operation %s: parameter %s requires the layer number %s, e.g. FC %s",
+				operationDefinition.Name, name, layerPlaceholder, layerPlaceholder)
+		}
+	}
+	switch result := stringParameter(operationDefinition, "result", PAWFraction); result {
+	case PAWFraction, PAWStorage:
+	default:
+		return fmt.Errorf("operation %s: unknown result %s, use fraction or mm", operationDefinition.Name, result)
+	}
+	if _, err := boolParameter(operationDefinition, "clip", true); err != nil {
+		return err
+	}
+	if _, err := boolParameter(operationDefinition, "percent", true); err != nil {
+		return err
+	}
+	if _, ok := operationDefinition.Parameters["depths"]; ok {
+		_, err := layerDepths(operationDefinition, 0)
+		return err
+	}
+	if _, ok := operationDefinition.Parameters["thickness"]; ok {
+		_, err := layerDepths(operationDefinition, 0)
+		return err
+	}
+	if stringParameter(operationDefinition, "result", PAWFraction) == PAWStorage {
+		return fmt.Errorf("operation %s: result mm requires the parameter depths or thickness", operationDefinition.Name)
+	}
+	return nil
+}
+
+// pawOperation computes the plant-available water (SoilW - WP) / (FC - WP) of the soil water columns,
+// a single layer or the profile of several layers, with the parameters
+// fc, wp: names of the field capacity and wilting point columns of the graph, with {n} for the layer number
+// of the soil water column, default FC {n} and WP {n}, a missing layer takes the columns of the nearest layer above
+// result: fraction (default) or mm of plant-available water
+// percent: fraction in percent (default) or as volume fraction
+// clip: limit the water of each layer to the range from wilting point to field capacity (default)
+// depths or thickness: layers in cm, required for mm, the fraction of the profile weights the layers by thickness
+func pawOperation(operationDefinition OperationDefinition, columnValues []*Column, context OperationContext, skipMissing bool) (*Column, error) {
+	if err := validatePAW(operationDefinition); err != nil {
+		return nil, err
+	}
+	result := stringParameter(operationDefinition, "result", PAWFraction)
+	clip, _ := boolParameter(operationDefinition, "clip", true)
+	percent, _ := boolParameter(operationDefinition, "percent", true)
+
+	// thickness of the layers in cm, equal layers if not given
+	thickness := repeatFloat(1, len(columnValues))
+	_, hasDepths := operationDefinition.Parameters["depths"]
+	_, hasThickness := operationDefinition.Parameters["thickness"]
+	if hasDepths || hasThickness {
+		depths, err := layerDepths(operationDefinition, len(columnValues))
+		if err != nil {
+			return nil, err
+		}
+		for i, depth := range depths {
+			thickness[i] = depth - depthAbove(depths, i)
+		}
+	}
+
+	// field capacity and wilting point of each layer, all as volume fractions
+	fcColumns := make([]*Column, len(columnValues))
+	wpColumns := make([]*Column, len(columnValues))
+	factors := make([][3]float64, len(columnValues))
+	for i, column := range columnValues {
+		layer, ok := layerNumber(column.Name)
+		if !ok {
+			return nil, fmt.Errorf("operation %s: column %s has no layer number", operationDefinition.Name, column.Name)
+		}
+		var err error
+		if fcColumns[i], err = layerColumn(operationDefinition, context, stringParameter(operationDefinition, "fc", "FC "+layerPlaceholder), layer); err != nil {
+			return nil, err
+		}
+		if wpColumns[i], err = layerColumn(operationDefinition, context, stringParameter(operationDefinition, "wp", "WP "+layerPlaceholder), layer); err != nil {
+			return nil, err
+		}
+		for j, source := range []*Column{column, fcColumns[i], wpColumns[i]} {
+			if source.Type != FloatColumn {
+				return nil, fmt.Errorf("operation %s: column %s is not numeric", operationDefinition.Name, source.Name)
+			}
+			if factors[i][j], err = volumeFraction(operationDefinition, source); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	newColumn := newResultColumn(columnValues[0].Len())
+	for row := 0; row < newColumn.Len(); row++ {
+		available := 0.0
+		capacity := 0.0
+		missing := false
+		for i, column := range columnValues {
+			if !column.Valid[row] || !fcColumns[i].Valid[row] || !wpColumns[i].Valid[row] {
+				missing = true
+				continue
+			}
+			water := column.Floats[row] * factors[i][0]
+			fieldCapacity := fcColumns[i].Floats[row] * factors[i][1]
+			wiltingPoint := wpColumns[i].Floats[row] * factors[i][2]
+			if fieldCapacity <= wiltingPoint {
+				missing = true
+				continue
+			}
+			if clip {
+				water = max(wiltingPoint, min(water, fieldCapacity))
+			}
+			available += (water - wiltingPoint) * thickness[i]
+			capacity += (fieldCapacity - wiltingPoint) * thickness[i]
+		}
+		if capacity == 0 || (missing && !skipMissing) {
+			continue
+		}
+		switch result {
+		case PAWStorage:
+			// a volume fraction times cm gives 10 mm
+			newColumn.Floats[row] = available * 10
+		default:
+			newColumn.Floats[row] = available / capacity
+			if percent {
+				newColumn.Floats[row] *= 100
+			}
+		}
+		newColumn.Valid[row] = true
+	}
+	switch {
+	case result == PAWStorage:
+		newColumn.Unit = "mm"
+	case percent:
+		newColumn.Unit = "%"
+	default:
+		newColumn.Unit = "1"
+	}
+	return newColumn, nil
+}
@@ -0,0 +1,166 @@
+package cropgraph
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// CatalogEntry describes a column of the simulation output
+type CatalogEntry struct {
+	// human-readable name, shown in legends instead of the column name
+	Label string `yaml:",omitempty"`
+	// description, shown in tooltips
+	Description string `yaml:",omitempty"`
+	// unit, used if the input file gives no unit
+	Unit string `yaml:",omitempty"`
+	// physical bounds, values outside are reported as data-quality warning
+	Min *float64 `yaml:",omitempty"`
+	Max *float64 `yaml:",omitempty"`
+	// aggregator used when a graph is resampled, e.g. sum for fluxes and mean for states
+	Aggregate string `yaml:",omitempty"`
+}
+
+// ColumnCatalog holds the catalog entries by column name or pattern (e.g. SoilW *)
+type ColumnCatalog map[string]CatalogEntry
+
+// Lookup returns the entry of the column, by name, by name ignoring whitespace and case, or by pattern
+func (catalog ColumnCatalog) Lookup(name string) (CatalogEntry, bool) {
+	if entry, ok := catalog[name]; ok {
+		return entry, true
+	}
+	keys := make([]string, 0, len(catalog))
+	for key := range catalog {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if normalizeColumnName(key) == normalizeColumnName(name) {
+			return catalog[key], true
+		}
+	}
+	for _, key := range keys {
+		if !isColumnPattern(key) {
+			continue
+		}
+		if pattern, err := parseColumnPattern(key); err == nil && pattern.Match(name) {
+			return catalog[key], true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// Label returns the label of the column, the column name if the catalog has no label
+func (catalog ColumnCatalog) Label(name string) string {
+	if entry, ok := catalog.Lookup(name); ok && entry.Label != "" {
+		return entry.Label
+	}
+	return name
+}
+
+// validate checks the patterns and bounds of the catalog
+func (catalog ColumnCatalog) validate() error {
+	for key, entry := range catalog {
+		if isColumnPattern(key) {
+			if _, err := parseColumnPattern(key); err != nil {
+				return fmt.Errorf("catalog: %w", err)
+			}
+		}
+		if entry.Min != nil && entry.Max != nil && *entry.Min > *entry.Max {
+			return fmt.Errorf("catalog: column %s: min %g is greater than max %g", key, *entry.Min, *entry.Max)
+		}
+		if _, ok := aggregators[entry.Aggregate]; !ok && entry.Aggregate != "" {
+			return fmt.Errorf("catalog: column %s: unknown aggregator %q", key, entry.Aggregate)
+		}
+	}
+	return nil
+}
+
+// readCatalogFile reads a column catalog, a relative path is relative to the directory of the config file
+func readCatalogFile(catalogFile, configFile string) (ColumnCatalog, error) {
+	catalog := ColumnCatalog{}
+	if err := readConfigRelativeYAML(catalogFile, configFile, &catalog); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// checkCatalog sets the catalog unit of a column without unit
+// and reports values outside the bounds of the catalog
+func (builder *tableBuilder) checkCatalog(column *Column) {
+	entry, ok := builder.catalog.Lookup(column.Name)
+	if !ok {
+		return
+	}
+	if column.Unit == "" {
+		column.Unit = entry.Unit
+	}
+	if column.Type != FloatColumn || (entry.Min == nil && entry.Max == nil) {
+		return
+	}
+	numOutside := 0
+	firstRow := 0
+	for row, value := range column.Floats {
+		if !column.Valid[row] {
+			continue
+		}
+		if (entry.Min != nil && value < *entry.Min) || (entry.Max != nil && value > *entry.Max) {
+			if numOutside == 0 {
+				firstRow = row
+			}
+			numOutside++
+		}
+	}
+	if numOutside > 0 {
+		bounds := fmt.Sprintf("[%s, %s]", formatBound(entry.Min, "-inf"), formatBound(entry.Max, "inf"))
+		location := builder.file
+		if firstRow < len(builder.lines) {
+			location = fmt.Sprintf("%s:%d", builder.file, builder.lines[firstRow])
+		}
+		fmt.Println("Warnung", location, "column", column.Name, "has", numOutside, "values outside", bounds)
+	}
+}
+
+// formatBound formats a bound of the catalog, unbounded if nil
+func formatBound(bound *float64, unbounded string) string {
+	if bound == nil {
+		return unbounded
+	}
+	return fmt.Sprint(*bound)
+}
+
+// descriptionTooltip returns an axis tooltip, which shows the description of each series below its value
+// the descriptions are given in the order of the series, empty if a series has no description
+func descriptionTooltip(descriptions []string) opts.Tooltip {
+	tooltip := opts.Tooltip{
+		Trigger: "axis",
+		Show:    true,
+	}
+	if strings.Join(descriptions, "") == "" {
+		return tooltip
+	}
+	// the function is embedded in a json string, so the descriptions are written as html
+	// in single quotes, without characters that json escapes
+	quoted := make([]string, len(descriptions))
+	for i, description := range descriptions {
+		description = strings.Join(strings.Fields(html.EscapeString(description)), " ")
+		quoted[i] = "'" + strings.ReplaceAll(description, `\`, "&#92;") + "'"
+	}
+	tooltip.Formatter = opts.FuncOpts(`function (params) {
+		var descriptions = [` + strings.Join(quoted, ", ") + `];
+		var items = [].concat(params);
+		var text = items.length > 0 ? items[0].axisValueLabel : '';
+		items.forEach(function (item) {
+			var value = Array.isArray(item.value) ? item.value[item.value.length - 1] : item.value;
+			text += '<br/>' + item.marker + item.seriesName + ': ' + value;
+			if (descriptions[item.seriesIndex]) {
+				text += '<br/><span style=font-size:smaller>' + descriptions[item.seriesIndex] + '</span>';
+			}
+		});
+		return text;
+	}`)
+	return tooltip
+}
@@ -1,129 +1,590 @@
 package cropgraph
 
-import "strconv"
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func HandleColumnViewOperation(operationDefinition OperationDefinition, columnValues [][]interface{}) []interface{} {
+// handling of missing values by column operations
+const (
+	// a missing input value makes the result missing
+	MissingPropagate = "propagate"
+	// missing input values are left out, the result is missing if no input value is given
+	MissingSkip = "skip"
+)
 
-	// TODO: throw an error if the columnValues are empty
+// OperationContext gives operations access to the graph beyond their own columns
+type OperationContext struct {
+	// date column of the graph, nil if the graph has no date column
+	Dates *Column
+	// columns of the graph by name, e.g. for a reset column
+	Columns map[string]*Column
+}
+
+// column returns the named column of the graph
+func (context OperationContext) column(operationDefinition OperationDefinition, name string) (*Column, error) {
+	column, ok := context.Columns[name]
+	if !ok {
+		return nil, fmt.Errorf("operation %s: column %s is not listed in the columns of the graph", operationDefinition.Name, name)
+	}
+	return column, nil
+}
+
+// dates returns the date column of the graph, required by date based operations
+func (context OperationContext) dates(operationDefinition OperationDefinition) (*Column, error) {
+	if context.Dates == nil || context.Dates.Type != TimeColumn {
+		return nil, fmt.Errorf("operation %s requires a date column", operationDefinition.Name)
+	}
+	return context.Dates, nil
+}
+
+// HandleColumnViewOperation applies the operation to the given columns and returns the resulting column
+// missing values are handled as defined by the "missing" parameter of the operation (default propagate)
+func HandleColumnViewOperation(operationDefinition OperationDefinition, columnValues []*Column, context OperationContext) (*Column, error) {
+
+	// the columns of an expression are given by the expression
+	if len(columnValues) == 0 && operationDefinition.Operation != "expr" {
+		return nil, fmt.Errorf("operation %s requires at least one column", operationDefinition.Name)
+	}
+	for _, column := range columnValues {
+		if column.Type != FloatColumn {
+			return nil, fmt.Errorf("operation %s: column %s is not numeric", operationDefinition.Name, column.Name)
+		}
+	}
+
+	missing := stringParameter(operationDefinition, "missing", MissingPropagate)
+	if missing != MissingPropagate && missing != MissingSkip {
+		return nil, fmt.Errorf("operation %s: unknown missing value handling %s", operationDefinition.Name, missing)
+	}
+	skipMissing := missing == MissingSkip
 
-	var newColumnValues []interface{}
+	var newColumn *Column
+	var err error
 	switch operationDefinition.Operation {
 	case "sum":
-		newColumnValues = sumOperation(columnValues)
+		newColumn = sumOperation(columnValues, skipMissing)
 	case "diff":
-		newColumnValues = diffOperation(columnValues)
+		newColumn = diffOperation(columnValues, skipMissing)
 	case "avg":
-		newColumnValues = avgOperation(columnValues)
+		newColumn = avgOperation(columnValues, skipMissing)
 	case "dailydifference":
-		newColumnValues = dailyDifferenceOperation(columnValues[0])
+		newColumn = dailyDifferenceOperation(columnValues[0], skipMissing)
+	case "cumsum":
+		newColumn, err = cumsumOperation(operationDefinition, columnValues, context, skipMissing)
+	case "rollingmean", "rollingsum", "rollingmin", "rollingmax":
+		newColumn, err = rollingOperation(operationDefinition, columnValues[0], skipMissing)
+	case "expr":
+		newColumn, err = exprOperation(operationDefinition, context)
+	case "convert":
+		newColumn, err = convertOperation(operationDefinition, columnValues[0])
+	case "gdd":
+		newColumn, err = gddOperation(operationDefinition, columnValues, context, skipMissing)
+	case "profile":
+		newColumn, err = profileOperation(operationDefinition, columnValues, skipMissing)
+	case "paw":
+		newColumn, err = pawOperation(operationDefinition, columnValues, context, skipMissing)
 	case "none":
-		newColumnValues = columnValues[0]
+		newColumn = columnValues[0].Copy()
+	default:
+		return nil, fmt.Errorf("operation %s: unknown operation type %s", operationDefinition.Name, operationDefinition.Operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	newColumn.Name = operationDefinition.Name
+	// the result keeps the unit of the first column, unless the operation sets a unit
+	if newColumn.Unit == "" && len(columnValues) > 0 {
+		newColumn.Unit = columnValues[0].Unit
+	}
+	// multiply each element in the result column
+	newColumn = MultiplyColumnValues(newColumn, operationDefinition.Multiply)
+
+	return newColumn, nil
+}
+
+// stringParameter returns the named parameter of the operation, or the default value if it is not set
+func stringParameter(operationDefinition OperationDefinition, name, defaultValue string) string {
+	if value, ok := operationDefinition.Parameters[name]; ok {
+		return fmt.Sprint(value)
+	}
+	return defaultValue
+}
+
+// intParameter returns the named integer parameter of the operation, or the default value if it is not set
+func intParameter(operationDefinition OperationDefinition, name string, defaultValue int) (int, error) {
+	value, ok := operationDefinition.Parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	switch number := value.(type) {
+	case int:
+		return number, nil
+	case float64:
+		if number == math.Trunc(number) {
+			return int(number), nil
+		}
+	case string:
+		if parsed, err := strconv.Atoi(strings.TrimSpace(number)); err == nil {
+			return parsed, nil
+		}
+	}
+	return 0, fmt.Errorf("operation %s: parameter %s must be an integer, found %v", operationDefinition.Name, name, value)
+}
+
+// floatParameter returns the named numeric parameter of the operation, or the default value if it is not set
+func floatParameter(operationDefinition OperationDefinition, name string, defaultValue float64) (float64, error) {
+	value, ok := operationDefinition.Parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	switch number := value.(type) {
+	case int:
+		return float64(number), nil
+	case float64:
+		return number, nil
+	case string:
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(number), 64); err == nil {
+			return parsed, nil
+		}
+	}
+	return 0, fmt.Errorf("operation %s: parameter %s must be a number, found %v", operationDefinition.Name, name, value)
+}
+
+// boolParameter returns the named boolean parameter of the operation, or the default value if it is not set
+func boolParameter(operationDefinition OperationDefinition, name string, defaultValue bool) (bool, error) {
+	value, ok := operationDefinition.Parameters[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	switch flag := value.(type) {
+	case bool:
+		return flag, nil
+	case string:
+		if parsed, err := strconv.ParseBool(strings.TrimSpace(flag)); err == nil {
+			return parsed, nil
+		}
+	}
+	return false, fmt.Errorf("operation %s: parameter %s must be true or false, found %v", operationDefinition.Name, name, value)
+}
+
+// withDefaultParameter returns the operation with the parameter set to the default value, if it is not set
+func withDefaultParameter(operationDefinition OperationDefinition, name string, defaultValue interface{}) OperationDefinition {
+	if _, ok := operationDefinition.Parameters[name]; ok {
+		return operationDefinition
+	}
+	parameters := make(map[string]interface{}, len(operationDefinition.Parameters)+1)
+	for key, value := range operationDefinition.Parameters {
+		parameters[key] = value
+	}
+	parameters[name] = defaultValue
+	operationDefinition.Parameters = parameters
+	return operationDefinition
+}
+
+// newResultColumn creates a numeric column of the given length with all values missing
+func newResultColumn(length int) *Column {
+	return &Column{
+		Type:   FloatColumn,
+		Floats: make([]float64, length),
+		Valid:  make([]bool, length),
 	}
-	// TODO: Implement multiplication for each element in the result column
-	newColumnValues = MultiplyColumnValues(newColumnValues, operationDefinition.Multiply)
+}
 
-	return newColumnValues
+// numValid counts the columns holding a value in the row
+func numValid(columnValues []*Column, row int) int {
+	count := 0
+	for _, column := range columnValues {
+		if column.Valid[row] {
+			count++
+		}
+	}
+	return count
+}
+
+// hasResult checks if a result can be calculated for the row
+// when propagating, all columns must hold a value, when skipping at least one
+func hasResult(columnValues []*Column, row int, skipMissing bool) bool {
+	count := numValid(columnValues, row)
+	if skipMissing {
+		return count > 0
+	}
+	return count == len(columnValues)
 }
 
-func sumOperation(columnValues [][]interface{}) []interface{} {
-	// TODO: Implement sum operation
-	// write a loop to iterate over the columnValues
-	// and sum up the values of each days entry into a new slice newColumnValues
-	// as a result, you should have one new slice of daily sums
-	newColumnValues := make([]interface{}, len(columnValues[0]))
+func sumOperation(columnValues []*Column, skipMissing bool) *Column {
+	// iterate over the columnValues
+	// and sum up the values of each days entry into a new column
+	// as a result, you should have one new column of daily sums
+	newColumn := newResultColumn(columnValues[0].Len())
 
-	for j := 0; j < len(columnValues[0]); j++ {
+	for j := 0; j < newColumn.Len(); j++ {
+		if !hasResult(columnValues, j, skipMissing) {
+			continue
+		}
 		sum := 0.0
 		for i := 0; i < len(columnValues); i++ {
-			sum = sum + AsFloat(columnValues[i][j])
+			if columnValues[i].Valid[j] {
+				sum = sum + columnValues[i].Floats[j]
+			}
 		}
-		newColumnValues[j] = sum
+		newColumn.Floats[j] = sum
+		newColumn.Valid[j] = true
 	}
-	return newColumnValues
+	return newColumn
 }
 
-func diffOperation(columnValues [][]interface{}) []interface{} {
-	// TODO: Implement diff operation
-	// write a loop to iterate over the columnValues
-	// and calculate the difference between the values of each days entry into a new slice newColumnValues
-	// as a result, you should have one new slice of daily differences between the values of each column
-	// e.g. columnValues[0][0] - columnValues[1][0] = newColumnValues[0]
-	newColumnValues := make([]interface{}, len(columnValues[0])) // create a new slice of columnValues []interfaceParseFloat()
-	for j := 0; j < len(columnValues[0]); j++ {
-		newColumnValues[j] = AsFloat(columnValues[0][j])
+func diffOperation(columnValues []*Column, skipMissing bool) *Column {
+	// iterate over the columnValues
+	// and calculate the difference between the values of each days entry into a new column
+	// as a result, you should have one new column of daily differences between the values of each column
+	// e.g. columnValues[0][0] - columnValues[1][0] = newColumn[0]
+	// the first column is always required, when skipping, missing values of the other columns are left out
+	newColumn := newResultColumn(columnValues[0].Len())
+	for j := 0; j < newColumn.Len(); j++ {
+		if !columnValues[0].Valid[j] || !hasResult(columnValues, j, skipMissing) {
+			continue
+		}
+		newColumn.Floats[j] = columnValues[0].Floats[j]
 		for i := 1; i < len(columnValues); i++ {
-			newColumnValues[j] = newColumnValues[j].(float64) - AsFloat(columnValues[i][j])
+			if columnValues[i].Valid[j] {
+				newColumn.Floats[j] = newColumn.Floats[j] - columnValues[i].Floats[j]
+			}
 		}
+		newColumn.Valid[j] = true
 	}
 
-	return newColumnValues
+	return newColumn
 }
 
-func avgOperation(columnValues [][]interface{}) []interface{} {
-	// TODO: Implement avg operation
-	// write a loop to iterate over the columnValues
-	// and calculate the average of the values of each days entry into a new slice newColumnValues
-	// as a result, you should have one new slice of daily averages between the values of each column
+func avgOperation(columnValues []*Column, skipMissing bool) *Column {
+	// iterate over the columnValues
+	// and calculate the average of the values of each days entry into a new column
+	// as a result, you should have one new column of daily averages between the values of each column
 	// the formula for the average is the sum of all values divided by the number of values
-	newColumnValues := make([]interface{}, len(columnValues[0])) // create a new slice of columnValues []interface{}
-	numColumnValues := float64(len(columnValues))
+	newColumn := newResultColumn(columnValues[0].Len())
 
-	for j := 0; j < len(columnValues[0]); j++ {
+	for j := 0; j < newColumn.Len(); j++ {
+		if !hasResult(columnValues, j, skipMissing) {
+			continue
+		}
 		sum := 0.0
 		for i := 0; i < len(columnValues); i++ {
-			sum = sum + AsFloat(columnValues[i][j])
+			if columnValues[i].Valid[j] {
+				sum = sum + columnValues[i].Floats[j]
+			}
+		}
+		newColumn.Floats[j] = sum / float64(numValid(columnValues, j))
+		newColumn.Valid[j] = true
+	}
+
+	return newColumn
+}
+
+func dailyDifferenceOperation(column *Column, skipMissing bool) *Column {
+	// please note that the input is a single column
+	// calculate the difference between two consecutive days into a new column
+	// e.g. column[1] - column[0] = newColumn[1]
+	// the first value of the newColumn should be 0, as there is no previous value to calculate the difference from
+	// when skipping, the difference is calculated to the last valid value
+	newColumn := newResultColumn(column.Len())
+	previous := -1
+	for i := 0; i < column.Len(); i++ {
+		if !column.Valid[i] {
+			if !skipMissing {
+				previous = -1
+			}
+			continue
+		}
+		if i == 0 || (skipMissing && previous < 0) {
+			newColumn.Floats[i] = 0.0
+			newColumn.Valid[i] = true
+		} else if previous >= 0 {
+			newColumn.Floats[i] = column.Floats[i] - column.Floats[previous]
+			newColumn.Valid[i] = true
+		}
+		previous = i
+	}
+
+	return newColumn
+}
+
+// cumsumOperation accumulates the row sums of the columns
+// the sum restarts at the dates of the "reset" parameter, a list of MM-DD (every year) or YYYY-MM-DD dates,
+// and whenever the value of the "resetcolumn" parameter column changes (e.g. a new season)
+// when propagating, a missing value makes the sum missing until the next reset, when skipping it adds nothing
+func cumsumOperation(operationDefinition OperationDefinition, columnValues []*Column, context OperationContext, skipMissing bool) (*Column, error) {
+	resets, err := parseResetDates(operationDefinition)
+	if err != nil {
+		return nil, err
+	}
+	var dates *Column
+	if len(resets) > 0 {
+		if dates, err = context.dates(operationDefinition); err != nil {
+			return nil, err
+		}
+	}
+	var trigger *Column
+	if name := stringParameter(operationDefinition, "resetcolumn", ""); name != "" {
+		if trigger, err = context.column(operationDefinition, name); err != nil {
+			return nil, err
+		}
+	}
+
+	rowSums := sumOperation(columnValues, skipMissing)
+	newColumn := newResultColumn(rowSums.Len())
+	sum := 0.0
+	// the sum is unknown after a missing value, until the next reset
+	unknown := false
+	previousDate := -1
+	for i := 0; i < rowSums.Len(); i++ {
+		reset := false
+		if dates != nil && dates.Valid[i] {
+			if previousDate >= 0 && resetBetween(resets, dates.Times[previousDate], dates.Times[i]) {
+				reset = true
+			}
+			previousDate = i
+		}
+		if trigger != nil && i > 0 && trigger.Valid[i] && trigger.Valid[i-1] && trigger.Value(i) != trigger.Value(i-1) {
+			reset = true
+		}
+		if reset {
+			sum = 0
+			unknown = false
+		}
+
+		if !rowSums.Valid[i] {
+			if !skipMissing {
+				unknown = true
+				continue
+			}
+		} else {
+			sum += rowSums.Floats[i]
+		}
+		if !unknown {
+			newColumn.Floats[i] = sum
+			newColumn.Valid[i] = true
+		}
+	}
+	return newColumn, nil
+}
+
+// resetDate is a reset date of an operation, every year if year is 0
+type resetDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// dateTexts returns the dates of the named parameter as texts, given as comma separated text, as yaml list
+// or as unquoted YYYY-MM-DD, which yaml decodes as time.Time
+func dateTexts(operationDefinition OperationDefinition, name string) []string {
+	value, ok := operationDefinition.Parameters[name]
+	if !ok {
+		return nil
+	}
+	items := []interface{}{value}
+	if list, ok := value.([]interface{}); ok {
+		items = list
+	}
+	texts := []string{}
+	for _, item := range items {
+		if date, ok := item.(time.Time); ok {
+			texts = append(texts, date.Format(isoDateFormat))
+			continue
+		}
+		for _, text := range strings.Split(fmt.Sprint(item), ",") {
+			if text = strings.TrimSpace(text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return texts
+}
+
+// dateParameter returns the named YYYY-MM-DD date parameter of the operation, false if it is not set
+func dateParameter(operationDefinition OperationDefinition, name string) (time.Time, bool, error) {
+	texts := dateTexts(operationDefinition, name)
+	if len(texts) == 0 {
+		return time.Time{}, false, nil
+	}
+	if len(texts) == 1 {
+		if date, err := time.Parse(isoDateFormat, texts[0]); err == nil {
+			return date, true, nil
+		}
+	}
+	return time.Time{}, true, fmt.Errorf("operation %s: invalid %s date %q, expected YYYY-MM-DD",
+		operationDefinition.Name, name, strings.Join(texts, ", "))
+}
+
+// parseResetDates reads the "reset" parameter, a list of MM-DD or YYYY-MM-DD dates
+func parseResetDates(operationDefinition OperationDefinition) ([]resetDate, error) {
+	resets := []resetDate{}
+	for _, text := range dateTexts(operationDefinition, "reset") {
+		if date, err := time.Parse("01-02", text); err == nil {
+			resets = append(resets, resetDate{month: date.Month(), day: date.Day()})
+		} else if date, err := time.Parse(isoDateFormat, text); err == nil {
+			resets = append(resets, resetDate{year: date.Year(), month: date.Month(), day: date.Day()})
+		} else {
+			return nil, fmt.Errorf("operation %s: invalid reset date %q, expected MM-DD or YYYY-MM-DD", operationDefinition.Name, text)
 		}
-		newColumnValues[j] = sum / numColumnValues
 	}
+	return resets, nil
+}
 
-	return newColumnValues
+// resetBetween checks if a reset date lies after the previous date, up to the current date
+func resetBetween(resets []resetDate, previous, current time.Time) bool {
+	for _, reset := range resets {
+		for year := previous.Year(); year <= current.Year(); year++ {
+			if reset.year != 0 && reset.year != year {
+				continue
+			}
+			date := time.Date(year, reset.month, reset.day, 0, 0, 0, 0, current.Location())
+			if date.After(previous) && !date.After(current) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-func dailyDifferenceOperation(columnValues []interface{}) []interface{} {
-	// TODO: Implement daily difference operation
-	// please note that the columnValues are now a slice of interface{} instead of a slice of []interface{}
-	// write a loop to iterate over the columnValues
-	// and calculate the difference between two consecutive days into a new slice newColumnValues
-	// as a result, you should have one new slice of daily differences between the values of each column
-	// e.g. columnValues[1] - columnValues[0] = newColumnValues[0]
-	// the first value of the newColumnValues should be 0, as there is no previous value to calculate the difference from
-	newColumnValues := make([]interface{}, len(columnValues)) // create a new slice of columnValues []interface{}
+// rollingOperation aggregates the values of a moving window of rows, with the parameters
+// window: number of rows, required
+// align: trailing (the window ends at the row, default) or center (the window is centered on the row)
+// minperiods: number of values required for a result, default the window size, or 1 when skipping missing values
+// when propagating, a missing value in the window makes the result missing
+func rollingOperation(operationDefinition OperationDefinition, column *Column, skipMissing bool) (*Column, error) {
+	window, minPeriods, offset, err := rollingParameters(operationDefinition, skipMissing)
+	if err != nil {
+		return nil, err
+	}
 
-	newColumnValues[0] = 0.0
-	for i := 1; i < len(columnValues); i++ {
-		newColumnValues[i] = AsFloat(columnValues[i]) - AsFloat(columnValues[i-1])
+	newColumn := newResultColumn(column.Len())
+	for i := 0; i < column.Len(); i++ {
+		values := make([]float64, 0, window)
+		numMissing := 0
+		for j := i + offset; j < i+offset+window; j++ {
+			if j < 0 || j >= column.Len() {
+				continue
+			}
+			if !column.Valid[j] {
+				numMissing++
+				continue
+			}
+			values = append(values, column.Floats[j])
+		}
+		if len(values) < minPeriods || (!skipMissing && numMissing > 0) {
+			continue
+		}
+		switch operationDefinition.Operation {
+		case "rollingsum", "rollingmean":
+			sum := 0.0
+			for _, value := range values {
+				sum += value
+			}
+			if operationDefinition.Operation == "rollingmean" {
+				sum /= float64(len(values))
+			}
+			newColumn.Floats[i] = sum
+		case "rollingmin":
+			newColumn.Floats[i] = slices.Min(values)
+		case "rollingmax":
+			newColumn.Floats[i] = slices.Max(values)
+		}
+		newColumn.Valid[i] = true
 	}
+	return newColumn, nil
+}
 
-	return newColumnValues
+// rollingParameters reads and checks the window size, the number of values required
+// and the offset of the first row of the window to the row of the result
+func rollingParameters(operationDefinition OperationDefinition, skipMissing bool) (int, int, int, error) {
+	window, err := intParameter(operationDefinition, "window", 0)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if window < 1 {
+		return 0, 0, 0, fmt.Errorf("operation %s: parameter window must be a positive number of rows", operationDefinition.Name)
+	}
+	defaultMinPeriods := window
+	if skipMissing {
+		defaultMinPeriods = 1
+	}
+	minPeriods, err := intParameter(operationDefinition, "minperiods", defaultMinPeriods)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if minPeriods < 1 || minPeriods > window {
+		return 0, 0, 0, fmt.Errorf("operation %s: parameter minperiods must be between 1 and the window size %d", operationDefinition.Name, window)
+	}
+	offset := 0
+	switch align := stringParameter(operationDefinition, "align", "trailing"); align {
+	case "trailing":
+		offset = -(window - 1)
+	case "center":
+		offset = -(window / 2)
+	default:
+		return 0, 0, 0, fmt.Errorf("operation %s: unknown alignment %s, use trailing or center", operationDefinition.Name, align)
+	}
+	return window, minPeriods, offset, nil
 }
 
-func AsFloat(value interface{}) float64 {
-	// check if the value is a string and convert it to a float64
-	// if the value is already a float64, return it
-	// if the value is not a string or a float64, panic
-	if _, ok := value.(float64); ok {
-		return value.(float64)
+// exprOperation evaluates the arithmetic formula of the "expression" parameter over the columns of the graph,
+// e.g. ("SoilW 1" - "WP 1") / ("FC 1" - "WP 1"), the result is missing where an input value is missing
+func exprOperation(operationDefinition OperationDefinition, context OperationContext) (*Column, error) {
+	text := stringParameter(operationDefinition, "expression", "")
+	if text == "" {
+		return nil, fmt.Errorf("operation %s requires the parameter expression", operationDefinition.Name)
+	}
+	node, err := parseExpression(text)
+	if err != nil {
+		return nil, fmt.Errorf("operation %s: %w", operationDefinition.Name, err)
 	}
+	newColumn, err := evaluateExpression(node, context.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("operation %s: %w", operationDefinition.Name, err)
+	}
+	return newColumn, nil
+}
 
-	if _, ok := value.(string); ok {
-		parsedValue, err := strconv.ParseFloat(value.(string), 64)
-		if err != nil {
-			panic(err)
+// convertOperation converts the column from the unit of the "from" parameter, default the unit of the column,
+// to the unit of the "to" parameter, which becomes the unit of the result
+func convertOperation(operationDefinition OperationDefinition, column *Column) (*Column, error) {
+	from := stringParameter(operationDefinition, "from", column.Unit)
+	to := stringParameter(operationDefinition, "to", "")
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("operation %s requires the parameters from and to, or a column with unit", operationDefinition.Name)
+	}
+	factor, offset, err := unitConversion(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("operation %s: %w", operationDefinition.Name, err)
+	}
+	newColumn := column.Copy()
+	for i := range newColumn.Floats {
+		if newColumn.Valid[i] {
+			newColumn.Floats[i] = newColumn.Floats[i]*factor + offset
 		}
-		return parsedValue
 	}
-	panic("value is not a float64 or a string")
+	newColumn.Unit = to
+	return newColumn, nil
 }
 
-func MultiplyColumnValues(columnValues []interface{}, factor float64) []interface{} {
-	// check if factor is 0 and return the columnValues as they are
-	// if factor is not 0, multiply each value in the columnValues with the factor
+// MultiplyColumnValues multiplies each value of a numeric column with the factor
+func MultiplyColumnValues(column *Column, factor float64) *Column {
+	// check if factor is 0 and return the column as it is
+	// if factor is not 0, multiply each value in the column with the factor
 	if factor == 0 || factor == 1 {
-		return columnValues
+		return column
 	}
 
-	for i := 0; i < len(columnValues); i++ {
-		columnValues[i] = AsFloat(columnValues[i]) * factor
+	for i := 0; i < column.Len(); i++ {
+		if !column.Valid[i] {
+			continue
+		}
+		column.Floats[i] = column.Floats[i] * factor
 	}
-	return columnValues
+	return column
 }
@@ -0,0 +1,96 @@
+package cropgraph
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// missingColumn returns a float column of the values, NaN marks a missing value
+func missingColumn(name string, values []float64) *Column {
+	column := NewFloatColumn(name, values)
+	for i, value := range values {
+		column.Valid[i] = !math.IsNaN(value)
+	}
+	return column
+}
+
+// resultValues returns the values of the column, NaN for missing values
+func resultValues(column *Column) []float64 {
+	values := make([]float64, column.Len())
+	for i := range values {
+		values[i] = math.NaN()
+		if column.Valid[i] {
+			values[i] = column.Floats[i]
+		}
+	}
+	return values
+}
+
+// equalValues compares values with a tolerance, NaN equals NaN
+func equalValues(values, want []float64) bool {
+	return slices.EqualFunc(values, want, func(a, b float64) bool {
+		return (math.IsNaN(a) && math.IsNaN(b)) || math.Abs(a-b) < 1e-9
+	})
+}
+
+func TestCumsum(t *testing.T) {
+	missing := math.NaN()
+	// saturday 2022-12-31 to tuesday 2023-01-03
+	dates, _ := dailyColumns(time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC), make([]float64, 4))
+	season := &Column{Name: "Season", Type: StringColumn, Strings: []string{"2022", "2022", "2022", "2023"}, Valid: []bool{true, true, true, true}}
+	context := OperationContext{Dates: dates, Columns: map[string]*Column{"Season": season}}
+	for _, test := range []struct {
+		name       string
+		parameters map[string]interface{}
+		values     []float64
+		want       []float64
+	}{
+		{"no reset", nil, []float64{1, 2, 3, 4}, []float64{1, 3, 6, 10}},
+		{"every year", map[string]interface{}{"reset": "01-01"}, []float64{1, 2, 3, 4}, []float64{1, 2, 5, 9}},
+		{"date", map[string]interface{}{"reset": "2023-01-02"}, []float64{1, 2, 3, 4}, []float64{1, 3, 3, 7}},
+		// yaml decodes an unquoted date as time.Time
+		{"unquoted date", map[string]interface{}{"reset": time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}, []float64{1, 2, 3, 4}, []float64{1, 2, 5, 9}},
+		{"date list", map[string]interface{}{"reset": []interface{}{"01-01", "2023-01-03"}}, []float64{1, 2, 3, 4}, []float64{1, 2, 5, 4}},
+		{"season", map[string]interface{}{"resetcolumn": "Season"}, []float64{1, 2, 3, 4}, []float64{1, 3, 6, 4}},
+		{"propagate until reset", map[string]interface{}{"reset": "01-01"}, []float64{missing, 2, 3, 4}, []float64{missing, 2, 5, 9}},
+		{"propagate within season", map[string]interface{}{"reset": "01-01"}, []float64{1, 2, missing, 4}, []float64{1, 2, missing, missing}},
+		{"skip", map[string]interface{}{"reset": "01-01", "missing": "skip"}, []float64{1, 2, missing, 4}, []float64{1, 2, 2, 6}},
+	} {
+		operation := OperationDefinition{Operation: "cumsum", Name: "cum", Parameters: test.parameters}
+		result, err := HandleColumnViewOperation(operation, []*Column{missingColumn("Precip", test.values)}, context)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if values := resultValues(result); !equalValues(values, test.want) {
+			t.Errorf("%s: cumsum = %v, want %v", test.name, values, test.want)
+		}
+	}
+}
+
+func TestCumsumResetRequiresDates(t *testing.T) {
+	operation := OperationDefinition{Operation: "cumsum", Name: "cum", Parameters: map[string]interface{}{"reset": "01-01"}}
+	if _, err := HandleColumnViewOperation(operation, []*Column{NewFloatColumn("Precip", []float64{1})}, OperationContext{}); err == nil {
+		t.Error("expected an error for a reset date without a date column")
+	}
+}
+
+func TestValidateOperation(t *testing.T) {
+	for _, test := range []struct {
+		operation  string
+		parameters map[string]interface{}
+	}{
+		{"sum", map[string]interface{}{"missing": "ignore"}},
+		{"cumsum", map[string]interface{}{"reset": "13-45"}},
+		{"rollingmean", nil},
+		{"rollingsum", map[string]interface{}{"window": 7, "align": "left"}},
+		{"rollingmax", map[string]interface{}{"window": 3, "minperiods": 5}},
+	} {
+		operation := OperationDefinition{Operation: test.operation, Name: "op", Columns: []string{"Precip"}, Parameters: test.parameters}
+		if err := validateOperation(operation, nil); err == nil {
+			t.Errorf("%s %v: expected an error", test.operation, test.parameters)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package cropgraph
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// prefix of a regular expression in a list of columns, e.g. re:^Nmin\d
+const regexPrefix = "re:"
+
+// columnPattern selects columns by a glob pattern (e.g. SoilW *) or a regular expression (e.g. re:^Nmin\d)
+type columnPattern struct {
+	// pattern as given in the config
+	text string
+	// compiled regular expression, nil for a glob pattern
+	regex *regexp.Regexp
+}
+
+// isColumnPattern checks if the column entry of the config is a pattern instead of a column name
+func isColumnPattern(text string) bool {
+	return strings.HasPrefix(text, regexPrefix) || strings.ContainsAny(text, "*?[")
+}
+
+// parseColumnPattern parses a glob pattern or a regular expression with prefix re:
+func parseColumnPattern(text string) (*columnPattern, error) {
+	if expression, ok := strings.CutPrefix(text, regexPrefix); ok {
+		regex, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid column pattern %q: %w", text, err)
+		}
+		return &columnPattern{text: text, regex: regex}, nil
+	}
+	if _, err := path.Match(text, ""); err != nil {
+		return nil, fmt.Errorf("invalid column pattern %q: %w", text, err)
+	}
+	return &columnPattern{text: text}, nil
+}
+
+// Match checks if the column name matches the pattern
+func (pattern *columnPattern) Match(name string) bool {
+	if pattern.regex != nil {
+		return pattern.regex.MatchString(name)
+	}
+	matched, _ := path.Match(pattern.text, name)
+	return matched
+}
+
+// columnSet selects the columns of an input file by name or pattern
+type columnSet struct {
+	names    map[string]bool
+	patterns []*columnPattern
+	// canonical column names by normalized source name
+	aliases map[string]string
+}
+
+func newColumnSet() *columnSet {
+	return &columnSet{names: map[string]bool{}, aliases: map[string]string{}}
+}
+
+// Add adds a column name or pattern, invalid patterns are only matched by name
+func (set *columnSet) Add(name string) {
+	set.names[name] = true
+	if isColumnPattern(name) {
+		if pattern, err := parseColumnPattern(name); err == nil {
+			set.patterns = append(set.patterns, pattern)
+		}
+	}
+}
+
+// Contains checks if the column is selected by name or by a pattern
+func (set *columnSet) Contains(name string) bool {
+	if set.names[name] {
+		return true
+	}
+	for _, pattern := range set.patterns {
+		if pattern.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve renames a column of the input file to its canonical name, if it is an alias,
+// and checks if the column is selected
+func (set *columnSet) Resolve(name string) (string, bool) {
+	if canonical, ok := set.aliases[normalizeColumnName(name)]; ok {
+		name = canonical
+	}
+	return name, set.Contains(name)
+}
+
+// expandColumns replaces the patterns of the list by the matching column names in natural sort order
+// a name of the list that is a column name is kept, even if it looks like a pattern
+func expandColumns(columns []string, names []string) ([]string, error) {
+	expanded := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !isColumnPattern(column) || slices.Contains(names, column) {
+			expanded = append(expanded, column)
+			continue
+		}
+		pattern, err := parseColumnPattern(column)
+		if err != nil {
+			return nil, err
+		}
+		matches := []string{}
+		for _, name := range names {
+			if pattern.Match(name) && !slices.Contains(expanded, name) && !slices.Contains(matches, name) {
+				matches = append(matches, name)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("column pattern %q matches no column", column)
+		}
+		slices.SortFunc(matches, compareNatural)
+		expanded = append(expanded, matches...)
+	}
+	return expanded, nil
+}
+
+// expandGraph expands the column patterns of the graph and its operations against the column names
+func expandGraph(graph GraphDefinition, names []string) (GraphDefinition, error) {
+	columns, err := expandColumns(graph.Columns, names)
+	if err != nil {
+		return graph, fmt.Errorf("graph %s: %w", graph.Title, err)
+	}
+	graph.Columns = columns
+	if graph.ColumnView != nil {
+		operations := make([]OperationDefinition, len(graph.ColumnView))
+		for i, operation := range graph.ColumnView {
+			if operation.Columns, err = expandColumns(operation.Columns, names); err != nil {
+				return graph, fmt.Errorf("graph %s: operation %s: %w", graph.Title, operation.Name, err)
+			}
+			operations[i] = operation
+		}
+		graph.ColumnView = operations
+	}
+	return graph, nil
+}
+
+// validateColumnPatterns checks the column patterns of the graph and its operations
+func validateColumnPatterns(graph GraphDefinition) error {
+	columns := slices.Clone(graph.Columns)
+	for _, operation := range graph.ColumnView {
+		columns = append(columns, operation.Columns...)
+	}
+	for _, column := range columns {
+		if isColumnPattern(column) {
+			if _, err := parseColumnPattern(column); err != nil {
+				return fmt.Errorf("graph %s: %w", graph.Title, err)
+			}
+		}
+	}
+	return nil
+}
+
+// compareNatural compares names with numbers by value, e.g. SoilW 2 before SoilW 10
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		numberA, restA := cutDigits(a)
+		numberB, restB := cutDigits(b)
+		if numberA != "" && numberB != "" {
+			// compare the numbers by length without leading zeros, then by digits
+			trimmedA := strings.TrimLeft(numberA, "0")
+			trimmedB := strings.TrimLeft(numberB, "0")
+			if len(trimmedA) != len(trimmedB) {
+				return len(trimmedA) - len(trimmedB)
+			}
+			if c := strings.Compare(trimmedA, trimmedB); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+// cutDigits splits the leading digits from the text
+func cutDigits(text string) (string, string) {
+	i := 0
+	for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+		i++
+	}
+	return text[:i], text[i:]
+}
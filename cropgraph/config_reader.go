@@ -1,27 +1,66 @@
 package cropgraph
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	// type of input file
+	// type of input file, selects the registered data source (e.g. HermesCSVOut)
 	InputType string
-	// number of header lines in the input file
-	NumHeader int
-	// date format of the input file
+	// number of header lines in the input file, inferred from the input file if omitted
+	NumHeader int `yaml:",omitempty"`
+	// date format of the input file, a go layout (02.01.2006) or strftime pattern (%d.%m.%Y),
+	// inferred from the input file if omitted
 	DateFormat string `yaml:",omitempty"`
-	// delimiter of the input file (e.g. tab, comma, space)
-	Delimiter string
+	// further date formats, tried in order if a date does not match DateFormat
+	DateFormats []string `yaml:",omitempty"`
+	// date columns built from split columns (e.g. YEAR and DOY)
+	DateColumns []DateColumnsDefinition `yaml:",omitempty"`
+	// delimiter of the input file, a single character or tab, comma, semicolon, pipe, space (runs of whitespace),
+	// inferred from the input file if omitted
+	Delimiter string `yaml:",omitempty"`
 	// theme of the graph
 	Theme string `yaml:",omitempty"`
 	// selected names of the columns
 	ColumnToGraph map[string]GraphDefinition
 	// multiple files to be plotted
 	MultiFiles bool `yaml:",omitempty"`
+	// tokens that mark a missing value in the input file (e.g. NA, *****), empty values are always missing
+	NATokens []string `yaml:",omitempty"`
+	// numeric values that mark a missing value in the input file (e.g. -99)
+	NAValues []float64 `yaml:",omitempty"`
+	// handling of missing values by column operations:
+	// propagate (a missing input value makes the result missing) or skip (missing input values are left out)
+	MissingValues string `yaml:",omitempty"`
+	// connect line chart series across missing values, instead of showing gaps
+	ConnectNulls bool `yaml:",omitempty"`
+	// decimal separator of numbers in the input file (e.g. "," for german output), default "."
+	DecimalSeparator string `yaml:",omitempty"`
+	// thousands separator of numbers in the input file, empty if numbers are not grouped
+	ThousandsSeparator string `yaml:",omitempty"`
+	// canonical column names with their names in other model versions (e.g. abovegrDryM: [AGB]),
+	// matched ignoring whitespace and case, in the graphs, the events and the column parameters of the operations
+	Aliases map[string][]string `yaml:",omitempty"`
+	// yaml file with further aliases, relative to the config file
+	AliasFile string `yaml:",omitempty"`
+	// labels, descriptions, units and valid ranges of the columns, by column name or pattern
+	Catalog ColumnCatalog `yaml:",omitempty"`
+	// yaml file with further catalog entries, relative to the config file
+	CatalogFile string `yaml:",omitempty"`
+	// events detected in the input file (e.g. stage changes), marked on the line, bar and kline graphs,
+	// ThemeRiver and bar3d graphs have no markers
+	Events []EventDefinition `yaml:",omitempty"`
+	// columns computed by operations, named by the operation, which can be used by all graphs,
+	// the events and other derived columns
+	Derived []OperationDefinition `yaml:",omitempty"`
+
+	// input formats inferred by the sniffer, which were reported, shared by the copies of the config
+	inferredFormats map[string]bool
 }
 
 type GraphDefinition struct {
@@ -35,6 +74,10 @@ type GraphDefinition struct {
 	DateColumn string
 	// operation to be applied to the columns
 	ColumnView []OperationDefinition `yaml:",omitempty"`
+	// draw one graph per unit, if the columns have different units
+	SplitUnits bool `yaml:",omitempty"`
+	// aggregate the rows to weeks, months, years or periods of days
+	Resample *ResampleDefinition `yaml:",omitempty"`
 }
 
 type OperationDefinition struct {
@@ -43,11 +86,24 @@ type OperationDefinition struct {
 	Operation string
 	// name of the column to be operated
 	Name string
-	// multiply factor
+	// multiply factor, not allowed with convert, which would label the multiplied values with the converted unit
 	Multiply float64 `yaml:",omitempty"`
 	// names of the columns to affected by the operation
 	Columns []string
 	// operation parameters
+	// missing: handling of missing values (propagate or skip), overrides Config.MissingValues
+	// reset: cumsum restarts at these dates, comma separated MM-DD (every year) or YYYY-MM-DD
+	// resetcolumn: cumsum restarts when the value of this column of the graph changes
+	// window, align, minperiods: window size in rows, alignment (trailing or center)
+	// and number of values required by the rolling operations
+	// expression: formula of expr over the columns of the graph, e.g. ("SoilW 1" - "WP 1") / ("FC 1" - "WP 1")
+	// from, to: units of convert, e.g. kg/ha and t/ha, from defaults to the unit of the column
+	// base, cutoff, method, accumulate, start: base temperature, upper cutoff, method (average, triangle or sine),
+	// accumulation (restarted by reset and resetcolumn) and start date of gdd
+	// depths or thickness, profilefile, top, bottom, result: lower boundaries or thicknesses of the layers in cm,
+	// yaml file with depths or thickness, depth interval in cm and result (mean, storage or total) of profile
+	// fc, wp, result, percent, clip: field capacity and wilting point columns with layer number {n} (default FC {n}
+	// and WP {n}), result (fraction or mm), fraction in percent and clipping of paw, which also takes depths or thickness
 	Parameters map[string]interface{} `yaml:",omitempty"`
 }
 
@@ -59,29 +115,244 @@ func ReadConfigFile(configFile string) (*Config, error) {
 	}
 
 	config := Config{
-		InputType:     "HermesCSVOut",
-		NumHeader:     1,
-		DateFormat:    "02.01.2006",
-		Delimiter:     ",",
-		Theme:         "calk",
-		ColumnToGraph: map[string]GraphDefinition{},
-		MultiFiles:    false,
+		InputType:        "HermesCSVOut",
+		Theme:            "calk",
+		ColumnToGraph:    map[string]GraphDefinition{},
+		MultiFiles:       false,
+		NATokens:         []string{"NA", "NaN", "nan", "-"},
+		MissingValues:    "propagate",
+		DecimalSeparator: ".",
+		inferredFormats:  map[string]bool{},
 	}
 	err = yaml.Unmarshal(fileData, &config)
 	if err != nil {
 		return nil, err
 	}
+	if config.DecimalSeparator != "" && config.DecimalSeparator == config.ThousandsSeparator {
+		return nil, fmt.Errorf("%s: decimal and thousands separator must differ", configFile)
+	}
+	if delimiter := config.delimiterCharacter(); delimiter != "" {
+		if config.DecimalSeparator == delimiter || config.ThousandsSeparator == delimiter {
+			return nil, fmt.Errorf("%s: decimal and thousands separator must differ from the delimiter", configFile)
+		}
+	}
+	if config.AliasFile != "" {
+		aliases, err := readAliasFile(config.AliasFile, configFile)
+		if err != nil {
+			return nil, err
+		}
+		if config.Aliases == nil {
+			config.Aliases = map[string][]string{}
+		}
+		for canonical, sourceNames := range aliases {
+			config.Aliases[canonical] = append(config.Aliases[canonical], sourceNames...)
+		}
+	}
+	if config.CatalogFile != "" {
+		catalog, err := readCatalogFile(config.CatalogFile, configFile)
+		if err != nil {
+			return nil, err
+		}
+		if config.Catalog == nil {
+			config.Catalog = ColumnCatalog{}
+		}
+		// entries of the config take precedence
+		for name, entry := range catalog {
+			if _, ok := config.Catalog[name]; !ok {
+				config.Catalog[name] = entry
+			}
+		}
+	}
+	if err := config.Catalog.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	aliases, err := config.columnAliases()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	// the graphs use the canonical column names
+	for graphName, graph := range config.ColumnToGraph {
+		config.ColumnToGraph[graphName] = canonicalGraph(graph, aliases)
+	}
+	if config.Derived != nil {
+		config.Derived = canonicalGraph(GraphDefinition{ColumnView: config.Derived}, aliases).ColumnView
+	}
+	for i, operation := range config.Derived {
+		if config.Derived[i], err = withProfileFile(operation, configFile); err != nil {
+			return nil, fmt.Errorf("%s: derived columns: %w", configFile, err)
+		}
+		if err := validateOperation(config.Derived[i], config.Catalog); err != nil {
+			return nil, fmt.Errorf("%s: derived columns: %w", configFile, err)
+		}
+	}
+	if _, err := derivedOrder(config.Derived); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	for i, event := range config.Events {
+		if canonical, ok := aliases[normalizeColumnName(event.Column)]; ok {
+			config.Events[i].Column = canonical
+		}
+		if err := event.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
+	}
+	if _, err := config.dateLayouts(); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	for _, graph := range config.ColumnToGraph {
+		// layer depths of the soil profile files
+		for i, operation := range graph.ColumnView {
+			if graph.ColumnView[i], err = withProfileFile(operation, configFile); err != nil {
+				return nil, fmt.Errorf("%s: graph %s: %w", configFile, graph.Title, err)
+			}
+		}
+		if err := validateColumnPatterns(graph); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
+		if graph.Resample != nil {
+			if err := graph.Resample.validate(); err != nil {
+				return nil, fmt.Errorf("%s: graph %s: %w", configFile, graph.Title, err)
+			}
+		}
+		for _, operation := range graph.ColumnView {
+			if err := validateOperation(operation, config.Catalog); err != nil {
+				return nil, fmt.Errorf("%s: graph %s: %w", configFile, graph.Title, err)
+			}
+		}
+		if len(config.Events) > 0 && !graphHasEventMarkers(graph.GraphType) {
+			fmt.Println("Warnung", "graph", graph.Title, "is a", graph.GraphType, "graph, which shows no event markers")
+		}
+	}
+	for _, definition := range config.DateColumns {
+		if err := definition.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
+	}
 
 	return &config, nil
 }
 
+// readConfigRelativeYAML reads a yaml file referenced by the config file into out,
+// a relative path is relative to the directory of the config file
+func readConfigRelativeYAML(path, configFile string, out interface{}) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(configFile), path)
+	}
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(fileData, out); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// validateOperation checks the parameters of an operation, which do not depend on the input file,
+// the catalog gives the units of the columns
+func validateOperation(operation OperationDefinition, catalog ColumnCatalog) error {
+	var err error
+	if missing := stringParameter(operation, "missing", MissingPropagate); missing != MissingPropagate && missing != MissingSkip {
+		return fmt.Errorf("operation %s: unknown missing value handling %s", operation.Name, missing)
+	}
+	switch operation.Operation {
+	case "expr":
+		_, err = parseExpression(stringParameter(operation, "expression", ""))
+	case "convert":
+		// the unit of the column defaults to the unit of the catalog
+		from := ""
+		if len(operation.Columns) > 0 {
+			if entry, ok := catalog.Lookup(operation.Columns[0]); ok {
+				from = entry.Unit
+			}
+		}
+		from = stringParameter(operation, "from", from)
+		to := stringParameter(operation, "to", "")
+		switch {
+		case to == "":
+			err = fmt.Errorf("convert requires the parameter to")
+		case operation.Multiply != 0 && operation.Multiply != 1:
+			err = fmt.Errorf("multiply changes the values converted to %s, convert to another unit instead", to)
+		case from != "":
+			_, _, err = unitConversion(from, to)
+		default:
+			_, err = parseUnit(to)
+		}
+	case "cumsum":
+		// the errors of the parameters name the operation
+		_, err = parseResetDates(operation)
+		return err
+	case "rollingmean", "rollingsum", "rollingmin", "rollingmax":
+		// minperiods is checked against the window, independent of the default of the missing value handling
+		_, _, _, err = rollingParameters(operation, false)
+		return err
+	case "gdd":
+		// the errors of the gdd parameters name the operation
+		_, err = parseGDDParameters(operation)
+		return err
+	case "profile":
+		return validateProfile(operation)
+	case "paw":
+		return validatePAW(operation)
+	}
+	if err != nil {
+		return fmt.Errorf("operation %s: %w", operation.Name, err)
+	}
+	return nil
+}
+
+// dateLayouts returns the go layouts of the date formats of the input file
+func (config Config) dateLayouts() ([]string, error) {
+	layouts := []string{}
+	for _, dateFormat := range append([]string{config.DateFormat}, config.DateFormats...) {
+		if dateFormat == "" {
+			continue
+		}
+		layout, err := ParseDateFormat(dateFormat)
+		if err != nil {
+			return nil, err
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// dateDisplayFormat returns the format used to show dates in the graphs
+func (config Config) dateDisplayFormat() string {
+	if layout, err := ParseDateFormat(config.DateFormat); err == nil && config.DateFormat != "" {
+		return layout
+	}
+	return isoDateFormat
+}
+
+// delimiterCharacter returns the delimiter of the config as character, empty if it is inferred or whitespace
+func (config Config) delimiterCharacter() string {
+	if named, ok := namedDelimiters[strings.ToLower(config.Delimiter)]; ok {
+		if named == "whitespace" {
+			return ""
+		}
+		return named
+	}
+	return config.Delimiter
+}
+
+// NumberFormat returns the number format of the input files
+func (config Config) NumberFormat() NumberFormat {
+	return NumberFormat{
+		DecimalSeparator:   config.DecimalSeparator,
+		ThousandsSeparator: config.ThousandsSeparator,
+	}
+}
+
 // write default config file
 func WriteDefaultConfigFile(configFile string) error {
 	config := Config{
-		InputType:  "HermesCSVOut",
-		NumHeader:  1,
-		Delimiter:  ",",
-		DateFormat: "02.01.2006",
+		InputType:     "HermesCSVOut",
+		NumHeader:     1,
+		Delimiter:     ",",
+		DateFormat:    "02.01.2006",
+		NATokens:      []string{"NA", "NaN", "nan", "-"},
+		MissingValues: "propagate",
 		ColumnToGraph: map[string]GraphDefinition{
 			"Graph1": {
 				GraphType:  "line",
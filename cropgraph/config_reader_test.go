@@ -0,0 +1,17 @@
+package cropgraph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes the yaml config to a temporary directory and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return configFile
+}
@@ -0,0 +1,146 @@
+package cropgraph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DataSource reads a simulation output file into columns of values
+// new output formats are added by registering a DataSource for their input type
+type DataSource interface {
+	// Read reads the input file, using the settings of the config
+	Read(inputFile string, config Config) (*SourceData, error)
+}
+
+// MultiRunSource is implemented by data sources whose input files can hold several simulation runs
+type MultiRunSource interface {
+	DataSource
+	// ReadRuns reads each run of the input file as a separate SourceData
+	ReadRuns(inputFile string, config Config) ([]*SourceData, error)
+}
+
+// SourceData holds the selected columns of a simulation output file
+type SourceData struct {
+	// name of the simulation run, if the input file holds several runs
+	Run string
+	// typed values of the selected columns
+	Table *Table
+	// index of each selected column in the input file, -1 for columns computed by the data source
+	ColumnIndex map[string]int
+}
+
+// registered data sources by input type
+var dataSources = map[string]DataSource{}
+
+// RegisterDataSource registers a data source for the given input type (Config.InputType)
+// registering an input type twice replaces the previous data source
+func RegisterDataSource(inputType string, source DataSource) {
+	if source == nil {
+		panic("cropgraph: RegisterDataSource source is nil")
+	}
+	dataSources[inputType] = source
+}
+
+// RegisteredInputTypes returns the sorted list of registered input types
+func RegisteredInputTypes() []string {
+	inputTypes := make([]string, 0, len(dataSources))
+	for inputType := range dataSources {
+		inputTypes = append(inputTypes, inputType)
+	}
+	slices.Sort(inputTypes)
+	return inputTypes
+}
+
+// GetDataSource returns the data source registered for the given input type
+func GetDataSource(inputType string) (DataSource, error) {
+	source, ok := dataSources[inputType]
+	if !ok {
+		return nil, fmt.Errorf("unknown input type %q, registered input types are: %s",
+			inputType, strings.Join(RegisteredInputTypes(), ", "))
+	}
+	return source, nil
+}
+
+// requiredColumns selects all columns used by the graphs of the config, by name or pattern
+func requiredColumns(config Config) *columnSet {
+	columns := newColumnSet()
+	// invalid aliases are reported when the config is read
+	if aliases, err := config.columnAliases(); err == nil {
+		columns.aliases = aliases
+	}
+	for _, graph := range config.ColumnToGraph {
+		for _, column := range graph.Columns {
+			columns.Add(column)
+		}
+	}
+	for _, event := range config.Events {
+		columns.Add(event.Column)
+	}
+	for _, operation := range config.Derived {
+		for _, column := range operationColumns(operation) {
+			columns.Add(column)
+		}
+	}
+	// split columns of the built date columns
+	for _, definition := range config.DateColumns {
+		for _, column := range definition.sourceColumns() {
+			columns.Add(column)
+		}
+	}
+	return columns
+}
+
+// dateColumns lists all columns used as date column by the graphs of the config
+func dateColumns(config Config) map[string]bool {
+	columns := map[string]bool{}
+	for _, graph := range config.ColumnToGraph {
+		if graph.DateColumn != "" {
+			columns[graph.DateColumn] = true
+		}
+	}
+	return columns
+}
+
+// ReadInputData reads the input file with the data source registered for config.InputType
+func ReadInputData(inputFile string, config Config) (*SourceData, error) {
+	source, err := GetDataSource(config.InputType)
+	if err != nil {
+		return nil, err
+	}
+	data, err := source.Read(inputFile, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := addDerivedColumns(config, data.Table); err != nil {
+		return nil, fmt.Errorf("%s: %w", inputFile, err)
+	}
+	return data, nil
+}
+
+// ReadInputRuns reads all simulation runs of the input file
+// data sources that do not implement MultiRunSource return a single run
+func ReadInputRuns(inputFile string, config Config) ([]*SourceData, error) {
+	source, err := GetDataSource(config.InputType)
+	if err != nil {
+		return nil, err
+	}
+	runs := []*SourceData{}
+	if multiRunSource, ok := source.(MultiRunSource); ok {
+		if runs, err = multiRunSource.ReadRuns(inputFile, config); err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := source.Read(inputFile, config)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, data)
+	}
+	for _, data := range runs {
+		if err := addDerivedColumns(config, data.Table); err != nil {
+			return nil, fmt.Errorf("%s: %w", inputFile, err)
+		}
+	}
+	return runs, nil
+}
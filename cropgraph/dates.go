@@ -0,0 +1,242 @@
+package cropgraph
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// go layouts of the strftime directives
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'p': "PM",
+	'z': "-0700",
+	'Z': "MST",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+	'%': "%",
+}
+
+// reference time to validate date layouts, all fields differ from the layout defaults
+var layoutCheckTime = time.Date(2023, time.November, 28, 13, 45, 56, 0, time.UTC)
+
+// ParseDateFormat converts a date format to a go layout
+// strftime patterns (e.g. %d.%m.%Y or %Y-%m-%d %H:%M) are converted, go layouts (e.g. 02.01.2006) are returned unchanged
+func ParseDateFormat(dateFormat string) (string, error) {
+	layout := dateFormat
+	if strings.Contains(dateFormat, "%") {
+		var converted strings.Builder
+		for i := 0; i < len(dateFormat); i++ {
+			if dateFormat[i] != '%' {
+				converted.WriteByte(dateFormat[i])
+				continue
+			}
+			if i+1 == len(dateFormat) {
+				return "", fmt.Errorf("date format %q ends with %%", dateFormat)
+			}
+			i++
+			directive, ok := strftimeDirectives[dateFormat[i]]
+			if !ok {
+				return "", fmt.Errorf("date format %q: unsupported directive %%%c", dateFormat, dateFormat[i])
+			}
+			converted.WriteString(directive)
+		}
+		layout = converted.String()
+	}
+	// a layout without date fields formats every time to the same text
+	if _, err := time.Parse(layout, layoutCheckTime.Format(layout)); err != nil || layout == layoutCheckTime.Format(layout) {
+		return "", fmt.Errorf("invalid date format %q", dateFormat)
+	}
+	return layout, nil
+}
+
+// parseTime parses the value with the first matching layout
+func parseTime(value string, layouts []string) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("no date format given")
+	}
+	return time.Time{}, err
+}
+
+// DateColumnsDefinition builds a date column from split columns,
+// either year and day of year, or year, month and day, with an optional time of day
+type DateColumnsDefinition struct {
+	// name of the built date column, default Date
+	Name string `yaml:",omitempty"`
+	// name of the year column
+	Year string
+	// name of the day of year column, used instead of month and day
+	DOY string `yaml:",omitempty"`
+	// name of the month column
+	Month string `yaml:",omitempty"`
+	// name of the day of month column
+	Day string `yaml:",omitempty"`
+	// name of the time of day column, decimal hours (e.g. 13.5) or clock time (e.g. 13:30)
+	Time string `yaml:",omitempty"`
+}
+
+// columnName returns the name of the built date column
+func (definition DateColumnsDefinition) columnName() string {
+	if definition.Name == "" {
+		return "Date"
+	}
+	return definition.Name
+}
+
+// sourceColumns lists the split columns of the date
+func (definition DateColumnsDefinition) sourceColumns() []string {
+	columns := []string{}
+	for _, column := range []string{definition.Year, definition.DOY, definition.Month, definition.Day, definition.Time} {
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// validate checks that the definition names a complete date
+func (definition DateColumnsDefinition) validate() error {
+	if definition.Year == "" {
+		return fmt.Errorf("date columns %s: year column is required", definition.columnName())
+	}
+	if definition.DOY == "" && (definition.Month == "" || definition.Day == "") {
+		return fmt.Errorf("date columns %s: day of year or month and day columns are required", definition.columnName())
+	}
+	return nil
+}
+
+// buildDateColumn builds the date column from the split columns of the table
+// a missing part makes the date missing, an invalid part is reported as ParseError
+func (builder *tableBuilder) buildDateColumn(table *Table, definition DateColumnsDefinition) (*Column, error) {
+	if err := definition.validate(); err != nil {
+		return nil, err
+	}
+	parts := map[string]*Column{}
+	for _, name := range definition.sourceColumns() {
+		column, ok := table.Column(name)
+		if !ok {
+			return nil, &ColumnNotFoundError{File: builder.file, Column: name}
+		}
+		parts[name] = column
+	}
+
+	length := table.Len()
+	dateColumn := &Column{
+		Name:  definition.columnName(),
+		Type:  TimeColumn,
+		Times: make([]time.Time, length),
+		Valid: make([]bool, length),
+	}
+	// integer value of a part
+	intPart := func(name string, row, min, max int) (int, bool, error) {
+		column := parts[name]
+		if !column.Valid[row] {
+			return 0, false, nil
+		}
+		value := column.Value(row)
+		number, isNumber := value.(float64)
+		if !isNumber || number != math.Trunc(number) || int(number) < min || int(number) > max {
+			return 0, false, builder.parseError(name, row, fmt.Sprint(value), fmt.Errorf("expected an integer in [%d, %d]", min, max))
+		}
+		return int(number), true, nil
+	}
+
+	for row := 0; row < length; row++ {
+		year, ok, err := intPart(definition.Year, row, 0, 9999)
+		if err != nil || !ok {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var date time.Time
+		if definition.DOY != "" {
+			doy, ok, err := intPart(definition.DOY, row, 1, 366)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			date = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, doy-1)
+		} else {
+			month, okMonth, err := intPart(definition.Month, row, 1, 12)
+			if err != nil {
+				return nil, err
+			}
+			day, okDay, err := intPart(definition.Day, row, 1, 31)
+			if err != nil {
+				return nil, err
+			}
+			if !okMonth || !okDay {
+				continue
+			}
+			date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+			if date.Day() != day {
+				return nil, builder.parseError(definition.Day, row, fmt.Sprint(day), fmt.Errorf("day does not exist in %d-%02d", year, month))
+			}
+		}
+		if definition.Time != "" {
+			timeOfDay, ok, err := builder.timeOfDay(parts[definition.Time], row)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			date = date.Add(timeOfDay)
+		}
+		dateColumn.Times[row] = date
+		dateColumn.Valid[row] = true
+	}
+	return dateColumn, nil
+}
+
+// timeOfDay reads the time of day from decimal hours or a clock time (15:04 or 15:04:05)
+func (builder *tableBuilder) timeOfDay(column *Column, row int) (time.Duration, bool, error) {
+	if !column.Valid[row] {
+		return 0, false, nil
+	}
+	switch column.Type {
+	case FloatColumn:
+		hours := column.Floats[row]
+		if hours < 0 || hours > 24 {
+			return 0, false, builder.parseError(column.Name, row, fmt.Sprint(hours), fmt.Errorf("expected hours in [0, 24]"))
+		}
+		return time.Duration(hours * float64(time.Hour)), true, nil
+	case TimeColumn:
+		clock := column.Times[row]
+		return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute +
+			time.Duration(clock.Second())*time.Second, true, nil
+	default:
+		clock, err := parseTime(strings.TrimSpace(column.Strings[row]), []string{"15:04:05", "15:04"})
+		if err != nil {
+			return 0, false, builder.parseError(column.Name, row, column.Strings[row], err)
+		}
+		return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute +
+			time.Duration(clock.Second())*time.Second, true, nil
+	}
+}
@@ -0,0 +1,121 @@
+package cropgraph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// operationColumns lists the columns an operation refers to by name or pattern,
+// its columns, the columns of an expression, the reset column and the field capacity and wilting point of paw
+func operationColumns(operation OperationDefinition) []string {
+	columns := slices.Clone(operation.Columns)
+	if operation.Operation == "expr" {
+		if node, err := parseExpression(stringParameter(operation, "expression", "")); err == nil {
+			columns = node.columnNames(columns)
+		}
+	}
+	if name := stringParameter(operation, "resetcolumn", ""); name != "" {
+		columns = append(columns, name)
+	}
+	if operation.Operation == "paw" {
+		for _, template := range []string{stringParameter(operation, "fc", "FC "+layerPlaceholder), stringParameter(operation, "wp", "WP "+layerPlaceholder)} {
+			columns = append(columns, strings.ReplaceAll(template, layerPlaceholder, "*"))
+		}
+	}
+	return columns
+}
+
+// derivedOrder returns the derived columns ordered by their dependencies, a derived column follows
+// the derived columns it refers to by name, a cycle of references is an error
+func derivedOrder(derived []OperationDefinition) ([]OperationDefinition, error) {
+	byName := map[string]int{}
+	for i, operation := range derived {
+		if operation.Name == "" {
+			return nil, fmt.Errorf("derived column %d has no name", i+1)
+		}
+		if _, ok := byName[operation.Name]; ok {
+			return nil, fmt.Errorf("derived column %s is defined twice", operation.Name)
+		}
+		byName[operation.Name] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(derived))
+	order := make([]OperationDefinition, 0, len(derived))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		switch states[i] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(slices.Clone(path[slices.Index(path, derived[i].Name):]), derived[i].Name)
+			return fmt.Errorf("derived columns refer to each other: %s", strings.Join(cycle, " -> "))
+		}
+		states[i] = visiting
+		path = append(path, derived[i].Name)
+		for _, column := range operationColumns(derived[i]) {
+			if dependency, ok := byName[column]; ok {
+				if err := visit(dependency, path); err != nil {
+					return err
+				}
+			}
+		}
+		states[i] = visited
+		order = append(order, derived[i])
+		return nil
+	}
+	for i := range derived {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// addDerivedColumns computes the derived columns of the config and adds them to the table,
+// patterns select the columns of the input file, derived columns are referred to by name
+func addDerivedColumns(config Config, table *Table) error {
+	if len(config.Derived) == 0 {
+		return nil
+	}
+	order, err := derivedOrder(config.Derived)
+	if err != nil {
+		return err
+	}
+	inputNames := slices.Clone(table.Names())
+	// derived columns may refer to all columns of the table and the derived columns before them
+	context := OperationContext{Dates: firstDateColumn(&config, table), Columns: map[string]*Column{}}
+	for _, name := range inputNames {
+		context.Columns[name], _ = table.Column(name)
+	}
+	for _, operation := range order {
+		if _, ok := table.Column(operation.Name); ok {
+			return fmt.Errorf("derived column %s is a column of the input file", operation.Name)
+		}
+		if operation.Columns, err = expandColumns(operation.Columns, inputNames); err != nil {
+			return fmt.Errorf("derived column %s: %w", operation.Name, err)
+		}
+		columnValues := make([]*Column, len(operation.Columns))
+		for i, column := range operation.Columns {
+			var ok bool
+			if columnValues[i], ok = context.Columns[column]; !ok {
+				return fmt.Errorf("derived column %s: column %s not found", operation.Name, column)
+			}
+		}
+		if config.MissingValues != "" {
+			operation = withDefaultParameter(operation, "missing", config.MissingValues)
+		}
+		newColumn, err := HandleColumnViewOperation(operation, columnValues, context)
+		if err != nil {
+			return fmt.Errorf("derived column %s: %w", operation.Name, err)
+		}
+		table.AddColumn(newColumn)
+		context.Columns[newColumn.Name] = newColumn
+	}
+	return nil
+}
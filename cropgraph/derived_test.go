@@ -0,0 +1,66 @@
+package cropgraph
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// exprColumn returns a derived column defined by an expression
+func exprColumn(name, expression string) OperationDefinition {
+	return OperationDefinition{Operation: "expr", Name: name, Parameters: map[string]interface{}{"expression": expression}}
+}
+
+func TestDerivedOrder(t *testing.T) {
+	derived := []OperationDefinition{
+		exprColumn("ratio", "grain / total"),
+		exprColumn("total", "grain + straw"),
+		{Operation: "cumsum", Name: "cumtotal", Columns: []string{"total"}},
+	}
+	order, err := derivedOrder(derived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, operation := range order {
+		names = append(names, operation.Name)
+	}
+	if want := []string{"total", "ratio", "cumtotal"}; !slices.Equal(names, want) {
+		t.Errorf("found order %v, want %v", names, want)
+	}
+}
+
+func TestDerivedOrderErrors(t *testing.T) {
+	for _, test := range []struct {
+		derived []OperationDefinition
+		want    string
+	}{
+		{[]OperationDefinition{exprColumn("a", "b + 1"), exprColumn("b", "c * 2"), exprColumn("c", "a - 1")},
+			"derived columns refer to each other: a -> b -> c -> a"},
+		{[]OperationDefinition{exprColumn("a", "a + 1")}, "derived columns refer to each other: a -> a"},
+		{[]OperationDefinition{exprColumn("a", "x"), exprColumn("a", "y")}, "derived column a is defined twice"},
+		{[]OperationDefinition{exprColumn("", "x")}, "derived column 1 has no name"},
+	} {
+		if _, err := derivedOrder(test.derived); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("found %v, want %s", err, test.want)
+		}
+	}
+}
+
+func TestAddDerivedColumns(t *testing.T) {
+	table := NewTable()
+	table.AddColumn(NewFloatColumn("grain", []float64{2, 4}))
+	table.AddColumn(NewFloatColumn("straw", []float64{6, 4}))
+	config := Config{Derived: []OperationDefinition{exprColumn("harvestindex", "grain / total"), exprColumn("total", "grain + straw")}}
+	if err := addDerivedColumns(config, table); err != nil {
+		t.Fatal(err)
+	}
+	if column, ok := table.Column("harvestindex"); !ok || !slices.Equal(column.Floats, []float64{0.25, 0.5}) {
+		t.Errorf("found harvest index %v", column)
+	}
+
+	config.Derived = []OperationDefinition{exprColumn("grain", "straw * 2")}
+	if err := addDerivedColumns(config, table); err == nil {
+		t.Error("expected an error for a derived column with the name of an input column")
+	}
+}
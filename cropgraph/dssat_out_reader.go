@@ -0,0 +1,136 @@
+package cropgraph
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterDataSource("DSSATOut", dssatOutSource{})
+}
+
+// dssatOutSource reads dssat fixed-width output files (e.g. PlantGro.OUT, SoilWat.OUT)
+//
+// a file contains one or more runs, each starting with a "*RUN" line,
+// the column names are given in a line starting with "@",
+// the values are separated by whitespace.
+// Missing values are written as -99.
+// Date columns of the config, which are not part of the file, are built from the YEAR and DOY columns.
+type dssatOutSource struct{}
+
+// Read reads the first run of the input file
+func (source dssatOutSource) Read(inputFile string, config Config) (*SourceData, error) {
+	runs, err := source.ReadRuns(inputFile, config)
+	if err != nil {
+		return nil, err
+	}
+	return runs[0], nil
+}
+
+// ReadRuns reads each run of the input file
+func (dssatOutSource) ReadRuns(inputFile string, config Config) ([]*SourceData, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	configColumns := requiredColumns(config)
+	buildDates := dateColumns(config)
+
+	runs := []*SourceData{}
+	var current *SourceData
+	var builder *tableBuilder
+	// index of the columns in the current table, nil until the first "@" line of a run
+	var header map[string]int
+	runName := ""
+
+	// finish parses the values of the current table
+	finish := func() error {
+		if builder == nil {
+			return nil
+		}
+		table, err := builder.Build()
+		if err != nil {
+			return err
+		}
+		current.Table = table
+		builder = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "*RUN"):
+			// a new run starts
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			runName = strings.Join(strings.Fields(strings.TrimPrefix(trimmed, "*")), " ")
+			header = nil
+		case strings.HasPrefix(trimmed, "@"):
+			// column names of the run
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			header = map[string]int{}
+			colNames := strings.Fields(strings.TrimPrefix(trimmed, "@"))
+			for colIndex, colName := range colNames {
+				header[colName] = colIndex
+			}
+			current = &SourceData{Run: runName, ColumnIndex: map[string]int{}}
+			builder = newTableBuilder(inputFile, config)
+			// dssat writes missing values as -99
+			builder.AddNAValues(-99)
+			for colName := range buildDates {
+				if _, ok := header[colName]; !ok && configColumns.Contains(colName) && !builder.isSplitDate(colName) {
+					current.ColumnIndex[colName] = -1
+					builder.AddSplitDate(DateColumnsDefinition{Name: colName, Year: "YEAR", DOY: "DOY"})
+					configColumns.Add("YEAR")
+					configColumns.Add("DOY")
+				}
+			}
+			for colIndex, colName := range colNames {
+				if colName, ok := configColumns.Resolve(colName); ok {
+					current.ColumnIndex[colName] = colIndex
+					builder.AddColumn(colName)
+				}
+			}
+			runs = append(runs, current)
+		case header == nil || trimmed == "" || strings.HasPrefix(trimmed, "*") ||
+			strings.HasPrefix(trimmed, "$") || strings.HasPrefix(trimmed, "!"):
+			// run information and comments
+			continue
+		default:
+			row := strings.Fields(trimmed)
+			if len(row) != len(header) {
+				return nil, &ParseError{File: inputFile, Line: lineNumber,
+					Err: fmt.Errorf("expected %d values, found %d", len(header), len(row))}
+			}
+			builder.StartRow(lineNumber)
+			for colName, colIndex := range current.ColumnIndex {
+				// built date columns have no index
+				if colIndex >= 0 {
+					builder.Append(colName, row[colIndex])
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("%s: no dssat output table found", inputFile)
+	}
+	return runs, nil
+}
@@ -0,0 +1,47 @@
+package cropgraph
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDSSATRuns(t *testing.T) {
+	config := Config{InputType: "DSSATOut", ColumnToGraph: map[string]GraphDefinition{
+		"g": {GraphType: "line", Columns: []string{"Date", "LAID", "DAP"}, DateColumn: "Date"}}}
+	runs, err := ReadInputRuns("../test_data/dssat/PlantGro.OUT", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Run != "RUN 1 : RAINFED LOW NITROGEN" || runs[1].Run != "RUN 2 : RAINFED HIGH NITROGEN" {
+		t.Fatalf("found runs %v", runs)
+	}
+	for i, want := range []struct {
+		dates []time.Time
+		laid  []float64
+		dap   []bool
+	}{
+		{[]time.Time{time.Date(1982, 6, 15, 0, 0, 0, 0, time.UTC), time.Date(1982, 6, 16, 0, 0, 0, 0, time.UTC), time.Date(1982, 6, 17, 0, 0, 0, 0, time.UTC)},
+			[]float64{0, 0.05, 0.1}, []bool{false, false, true}},
+		// the second run crosses the turn of the year
+		{[]time.Time{time.Date(1982, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(1983, 1, 1, 0, 0, 0, 0, time.UTC)},
+			[]float64{0.1, 0.2}, []bool{false, true}},
+	} {
+		date, _ := runs[i].Table.Column("Date")
+		laid, _ := runs[i].Table.Column("LAID")
+		dap, _ := runs[i].Table.Column("DAP")
+		if date == nil || laid == nil || dap == nil {
+			t.Fatalf("run %d: found columns %v", i+1, runs[i].Table.Names())
+		}
+		if !slices.EqualFunc(date.Times, want.dates, time.Time.Equal) {
+			t.Errorf("run %d: found dates %v, want %v", i+1, date.Times, want.dates)
+		}
+		if !slices.Equal(laid.Floats, want.laid) {
+			t.Errorf("run %d: found LAID %v, want %v", i+1, laid.Floats, want.laid)
+		}
+		// -99 is missing
+		if !slices.Equal(dap.Valid, want.dap) {
+			t.Errorf("run %d: found valid DAP %v, want %v", i+1, dap.Valid, want.dap)
+		}
+	}
+}
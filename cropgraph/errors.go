@@ -0,0 +1,62 @@
+package cropgraph
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseError reports a value of an input file that could not be read
+type ParseError struct {
+	// name of the input file
+	File string
+	// line number in the input file, 0 if unknown
+	Line int
+	// name of the column, empty if the whole line could not be read
+	Column string
+	// raw value that could not be parsed
+	Value string
+	// underlying error
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var location strings.Builder
+	location.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&location, ":%d", e.Line)
+	}
+	if e.Column != "" {
+		fmt.Fprintf(&location, ": column %q", e.Column)
+	}
+	if e.Value != "" {
+		return fmt.Sprintf("%s: invalid value %q: %v", location.String(), e.Value, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", location.String(), e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// readError converts an error of a csv reader into a ParseError of the input file
+func readError(inputFile string, err error) *ParseError {
+	var csvErr *csv.ParseError
+	if errors.As(err, &csvErr) {
+		return &ParseError{File: inputFile, Line: csvErr.Line, Err: csvErr.Err}
+	}
+	return &ParseError{File: inputFile, Err: err}
+}
+
+// ColumnNotFoundError reports a column of the config that is missing in the input file
+type ColumnNotFoundError struct {
+	// name of the input file
+	File string
+	// name of the missing column
+	Column string
+}
+
+func (e *ColumnNotFoundError) Error() string {
+	return fmt.Sprintf("%s: column %q not found in the input file", e.File, e.Column)
+}
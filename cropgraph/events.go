@@ -0,0 +1,284 @@
+package cropgraph
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// kinds of events
+const (
+	// the value of the column changes, e.g. a new development stage
+	EventChange = "change"
+	// the column falls below the threshold
+	EventBelow = "below"
+	// the column rises above the threshold
+	EventAbove = "above"
+	// the column has a local maximum
+	EventPeak = "peak"
+)
+
+// rows before and after a peak, the peak is the maximum of
+const defaultPeakWindow = 30
+
+// EventDefinition defines events detected in a column of the input file
+type EventDefinition struct {
+	// name of the event, shown at the marker, default the column and the kind of event
+	Name string `yaml:",omitempty"`
+	// kind of event: change, below, above or peak
+	Type string
+	// column of the input file
+	Column string
+	// threshold of below and above, minimum value of a peak
+	Threshold *float64 `yaml:",omitempty"`
+	// number of rows before and after a peak, the peak is the maximum of, default 30
+	Window int `yaml:",omitempty"`
+}
+
+// validate checks the kind of event and its parameters
+func (definition EventDefinition) validate() error {
+	if definition.Column == "" {
+		return fmt.Errorf("event %s: column is missing", definition.Name)
+	}
+	switch definition.Type {
+	case EventChange, EventPeak:
+	case EventBelow, EventAbove:
+		if definition.Threshold == nil {
+			return fmt.Errorf("event %s: %s requires a threshold", definition.label(), definition.Type)
+		}
+	default:
+		return fmt.Errorf("event %s: unknown type %q, use change, below, above or peak", definition.label(), definition.Type)
+	}
+	if definition.Window < 0 {
+		return fmt.Errorf("event %s: window must not be negative", definition.label())
+	}
+	return nil
+}
+
+// label returns the name of the event, or a name made of the column and the kind of event
+func (definition EventDefinition) label() string {
+	if definition.Name != "" {
+		return definition.Name
+	}
+	switch definition.Type {
+	case EventBelow, EventAbove:
+		if definition.Threshold != nil {
+			return fmt.Sprintf("%s %s %g", definition.Column, definition.Type, *definition.Threshold)
+		}
+	case EventChange:
+		return definition.Column
+	}
+	return fmt.Sprintf("%s %s", definition.Column, definition.Type)
+}
+
+// Event is an event detected in a row of the input file
+type Event struct {
+	// name of the event, with the new value of a change
+	Name string
+	// column the event was detected in
+	Column string
+	// row of the event
+	Row int
+	// value of the column in the row
+	Value interface{}
+}
+
+// detectEvents finds the events of the definitions in the table, ordered by row
+func detectEvents(definitions []EventDefinition, table *Table) ([]Event, error) {
+	events := []Event{}
+	for _, definition := range definitions {
+		column, ok := table.Column(definition.Column)
+		if !ok {
+			return nil, fmt.Errorf("event %s: column %s not found", definition.label(), definition.Column)
+		}
+		if definition.Type != EventChange && column.Type != FloatColumn {
+			return nil, fmt.Errorf("event %s: column %s is not numeric", definition.label(), definition.Column)
+		}
+		rows := []int{}
+		switch definition.Type {
+		case EventChange:
+			rows = changeRows(column)
+		case EventBelow, EventAbove:
+			rows = crossingRows(column, *definition.Threshold, definition.Type == EventAbove)
+		case EventPeak:
+			window := definition.Window
+			if window == 0 {
+				window = defaultPeakWindow
+			}
+			threshold := math.Inf(-1)
+			if definition.Threshold != nil {
+				threshold = *definition.Threshold
+			}
+			rows = peakRows(column, window, threshold)
+		}
+		for _, row := range rows {
+			name := definition.label()
+			if definition.Type == EventChange {
+				name = fmt.Sprintf("%s %v", name, column.Value(row))
+			}
+			events = append(events, Event{Name: name, Column: definition.Column, Row: row, Value: column.Value(row)})
+		}
+	}
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return a.Row - b.Row
+	})
+	return events, nil
+}
+
+// changeRows returns the rows with a value different from the previous valid value
+func changeRows(column *Column) []int {
+	rows := []int{}
+	previous := -1
+	for row := 0; row < column.Len(); row++ {
+		if !column.Valid[row] {
+			continue
+		}
+		if previous >= 0 && column.Value(row) != column.Value(previous) {
+			rows = append(rows, row)
+		}
+		previous = row
+	}
+	return rows
+}
+
+// crossingRows returns the rows where the column falls below the threshold, or rises above it
+func crossingRows(column *Column, threshold float64, above bool) []int {
+	rows := []int{}
+	previous := -1
+	for row := 0; row < column.Len(); row++ {
+		if !column.Valid[row] {
+			continue
+		}
+		if previous >= 0 {
+			before, value := column.Floats[previous], column.Floats[row]
+			if (above && before <= threshold && value > threshold) || (!above && before >= threshold && value < threshold) {
+				rows = append(rows, row)
+			}
+		}
+		previous = row
+	}
+	return rows
+}
+
+// peakRows returns the rows with the maximum of the window rows before and after, at least the threshold,
+// the first row of a plateau is the peak and a window of equal values has no peak
+func peakRows(column *Column, window int, threshold float64) []int {
+	rows := []int{}
+	for row := 0; row < column.Len(); row++ {
+		if !column.Valid[row] || column.Floats[row] < threshold {
+			continue
+		}
+		value := column.Floats[row]
+		isPeak := true
+		lower := false
+		for other := max(0, row-window); other <= min(column.Len()-1, row+window) && isPeak; other++ {
+			if other == row || !column.Valid[other] {
+				continue
+			}
+			if column.Floats[other] > value || (other < row && column.Floats[other] == value) {
+				isPeak = false
+			}
+			if column.Floats[other] < value {
+				lower = true
+			}
+		}
+		if isPeak && lower {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// firstDateColumn returns the date column of the first graph, which has one,
+// used by the summary of the events and the derived columns
+func firstDateColumn(config *Config, table *Table) *Column {
+	graphNames := make([]string, 0, len(config.ColumnToGraph))
+	for graphName := range config.ColumnToGraph {
+		graphNames = append(graphNames, graphName)
+	}
+	slices.Sort(graphNames)
+	for _, graphName := range graphNames {
+		if column, ok := table.Column(config.ColumnToGraph[graphName].DateColumn); ok && column.Type == TimeColumn {
+			return column
+		}
+	}
+	return nil
+}
+
+// printEvents lists the events of the input file with their dates, or rows if there is no date column
+func printEvents(inputFile string, events []Event, dateColumn *Column, dateFormat string) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Println("Events", inputFile)
+	for _, event := range events {
+		when := fmt.Sprint("row ", event.Row+1)
+		if dateColumn != nil && event.Row < dateColumn.Len() && dateColumn.Valid[event.Row] {
+			when = dateColumn.Times[event.Row].Format(dateFormat)
+		}
+		fmt.Printf("  %s %s (%s %v)\n", when, event.Name, event.Column, event.Value)
+	}
+}
+
+// eventMarkLine is the position of an event on the x axis of a graph
+type eventMarkLine struct {
+	name string
+	x    interface{}
+}
+
+// eventMarkLines returns the positions of the events on the x axis, a date on a time axis,
+// the formatted date or the row number on a category axis
+func eventMarkLines(events []Event, dateColumn *Column, dateFormat string) []eventMarkLine {
+	markLines := make([]eventMarkLine, 0, len(events))
+	var categories []string
+	if dateColumn != nil && !hasTimeAxis(dateColumn) {
+		categories = dateColumn.FormatTimes(dateFormat)
+	}
+	for _, event := range events {
+		switch {
+		case dateColumn == nil:
+			markLines = append(markLines, eventMarkLine{name: event.Name, x: fmt.Sprint(event.Row)})
+		case event.Row >= dateColumn.Len() || !dateColumn.Valid[event.Row]:
+			continue
+		case hasTimeAxis(dateColumn):
+			markLines = append(markLines, eventMarkLine{name: event.Name, x: dateColumn.Times[event.Row].Format(timeAxisLayout)})
+		default:
+			markLines = append(markLines, eventMarkLine{name: event.Name, x: categories[event.Row]})
+		}
+	}
+	return markLines
+}
+
+// graphHasEventMarkers reports whether the events are marked on the graph type,
+// the axes of ThemeRiver and bar3d graphs have no mark lines
+func graphHasEventMarkers(graphType string) bool {
+	return graphType != "ThemeRiver" && graphType != "bar3d"
+}
+
+// markEvents draws the events on the first series of a graph
+func markEvents(series charts.MultiSeries, markLines []eventMarkLine) {
+	if len(series) > 0 && len(markLines) > 0 {
+		series[0].ConfigureSeriesOpts(withEventMarkLines(markLines)...)
+	}
+}
+
+// withEventMarkLines draws the events as vertical lines, labeled with the name of the event
+func withEventMarkLines(markLines []eventMarkLine) []charts.SeriesOpts {
+	if len(markLines) == 0 {
+		return nil
+	}
+	items := make([]opts.MarkLineNameXAxisItem, len(markLines))
+	for i, markLine := range markLines {
+		items[i] = opts.MarkLineNameXAxisItem{Name: markLine.name, XAxis: markLine.x}
+	}
+	return []charts.SeriesOpts{
+		charts.WithMarkLineNameXAxisItemOpts(items...),
+		charts.WithMarkLineStyleOpts(opts.MarkLineStyle{
+			Symbol: []string{"none", "none"},
+			Label:  &opts.Label{Show: true, Formatter: "{b}"},
+		}),
+	}
+}
@@ -0,0 +1,527 @@
+package cropgraph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// an expression of the expr operation, evaluated for all rows at once
+//
+// column names without spaces or operators can be written as they are (Yield / abovegrDryM),
+// other names are quoted ("SoilW 1" - 'WP 1'),
+// operators are + - * / ^, comparisons < <= > >= == != and the logical && || !, which return 1 or 0,
+// functions are min, max, abs, sqrt, log, exp and if(condition, then, else)
+type expression interface {
+	// eval returns the values of the rows and their validity
+	eval(columns map[string]*Column, rows int) ([]float64, []bool)
+	// columnNames appends the names of the columns used by the expression
+	columnNames(names []string) []string
+}
+
+// parseExpression parses the expression of an expr operation
+func parseExpression(text string) (expression, error) {
+	tokens, err := tokenizeExpression(text)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", text, err)
+	}
+	parser := &expressionParser{tokens: tokens}
+	node, err := parser.parseOr()
+	if err == nil && parser.position < len(parser.tokens) {
+		err = fmt.Errorf("unexpected %s", parser.tokens[parser.position].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", text, err)
+	}
+	return node, nil
+}
+
+// evaluateExpression evaluates the expression over the rows of the columns
+// rows with a missing input value, or a result that is not a finite number, are missing
+func evaluateExpression(node expression, columns map[string]*Column) (*Column, error) {
+	rows := -1
+	for _, name := range node.columnNames(nil) {
+		column, ok := columns[name]
+		if !ok {
+			return nil, fmt.Errorf("column %s is not listed in the columns of the graph", name)
+		}
+		if column.Type != FloatColumn {
+			return nil, fmt.Errorf("column %s is not numeric", name)
+		}
+		rows = column.Len()
+	}
+	if rows < 0 {
+		// constant expression, one row per row of the graph
+		for _, column := range columns {
+			rows = column.Len()
+			break
+		}
+	}
+	values, valid := node.eval(columns, max(rows, 0))
+	newColumn := newResultColumn(len(values))
+	for i, value := range values {
+		if valid[i] && !math.IsNaN(value) && !math.IsInf(value, 0) {
+			newColumn.Floats[i] = value
+			newColumn.Valid[i] = true
+		}
+	}
+	return newColumn, nil
+}
+
+type tokenKind int
+
+const (
+	numberToken tokenKind = iota
+	nameToken
+	operatorToken
+)
+
+type expressionToken struct {
+	kind tokenKind
+	text string
+	// a quoted name is always a column name
+	quoted bool
+	// position of the token in the runes of the expression
+	start, end int
+}
+
+// operators of the expression, longer operators first
+var expressionOperators = []string{"<=", ">=", "==", "!=", "&&", "||", "+", "-", "*", "/", "^", "(", ")", ",", "<", ">", "!"}
+
+func tokenizeExpression(text string) ([]expressionToken, error) {
+	tokens := []expressionToken{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated column name %s", string(runes[i:]))
+			}
+			tokens = append(tokens, expressionToken{kind: nameToken, text: string(runes[i+1 : end]), quoted: true, start: i, end: end + 1})
+			i = end + 1
+		case unicode.IsDigit(r) || r == '.':
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			// exponent, e.g. 1e-3
+			if end < len(runes) && (runes[end] == 'e' || runes[end] == 'E') {
+				exponent := end + 1
+				if exponent < len(runes) && (runes[exponent] == '+' || runes[exponent] == '-') {
+					exponent++
+				}
+				if exponent < len(runes) && unicode.IsDigit(runes[exponent]) {
+					end = exponent
+					for end < len(runes) && unicode.IsDigit(runes[end]) {
+						end++
+					}
+				}
+			}
+			tokens = append(tokens, expressionToken{kind: numberToken, text: string(runes[i:end]), start: i, end: end})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, expressionToken{kind: nameToken, text: string(runes[i:end]), start: i, end: end})
+			i = end
+		default:
+			matched := false
+			for _, operator := range expressionOperators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, expressionToken{kind: operatorToken, text: operator, start: i, end: i + len([]rune(operator))})
+					i += len([]rune(operator))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// isFunctionCall checks if the name token at the position is the name of a function call
+func isFunctionCall(tokens []expressionToken, position int) bool {
+	_, ok := expressionFunctions[tokens[position].text]
+	return ok && !tokens[position].quoted && position+1 < len(tokens) &&
+		tokens[position+1].kind == operatorToken && tokens[position+1].text == "("
+}
+
+// renameExpressionColumns replaces the column names of the expression with their new names,
+// renamed columns are quoted, an expression, which can not be read, is returned unchanged
+func renameExpressionColumns(text string, rename func(name string) string) string {
+	tokens, err := tokenizeExpression(text)
+	if err != nil {
+		return text
+	}
+	runes := []rune(text)
+	renamed := []rune{}
+	last := 0
+	for i, token := range tokens {
+		if token.kind != nameToken || isFunctionCall(tokens, i) {
+			continue
+		}
+		name := rename(token.text)
+		if name == token.text {
+			continue
+		}
+		quote := `"`
+		if strings.Contains(name, quote) {
+			quote = "'"
+		}
+		renamed = append(append(renamed, runes[last:token.start]...), []rune(quote+name+quote)...)
+		last = token.end
+	}
+	return string(append(renamed, runes[last:]...))
+}
+
+// expressionParser is a recursive descent parser, from the lowest to the highest precedence:
+// ||, &&, comparisons, + -, * /, unary - + !, ^ (right associative)
+type expressionParser struct {
+	tokens   []expressionToken
+	position int
+}
+
+// accept consumes the next token, if it is one of the operators
+func (parser *expressionParser) accept(operators ...string) (string, bool) {
+	if parser.position >= len(parser.tokens) {
+		return "", false
+	}
+	token := parser.tokens[parser.position]
+	if token.kind != operatorToken {
+		return "", false
+	}
+	for _, operator := range operators {
+		if token.text == operator {
+			parser.position++
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+// parseBinary parses a left associative sequence of operands
+func (parser *expressionParser) parseBinary(operand func() (expression, error), operators ...string) (expression, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := parser.accept(operators...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (parser *expressionParser) parseOr() (expression, error) {
+	return parser.parseBinary(parser.parseAnd, "||")
+}
+
+func (parser *expressionParser) parseAnd() (expression, error) {
+	return parser.parseBinary(parser.parseComparison, "&&")
+}
+
+func (parser *expressionParser) parseComparison() (expression, error) {
+	return parser.parseBinary(parser.parseSum, "<=", ">=", "==", "!=", "<", ">")
+}
+
+func (parser *expressionParser) parseSum() (expression, error) {
+	return parser.parseBinary(parser.parseProduct, "+", "-")
+}
+
+func (parser *expressionParser) parseProduct() (expression, error) {
+	return parser.parseBinary(parser.parseUnary, "*", "/")
+}
+
+func (parser *expressionParser) parseUnary() (expression, error) {
+	if operator, ok := parser.accept("-", "+", "!"); ok {
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{operator: operator, operand: operand}, nil
+	}
+	return parser.parsePower()
+}
+
+func (parser *expressionParser) parsePower() (expression, error) {
+	base, err := parser.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parser.accept("^"); ok {
+		exponent, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{operator: "^", left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+func (parser *expressionParser) parsePrimary() (expression, error) {
+	if parser.position >= len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := parser.tokens[parser.position]
+	parser.position++
+	switch token.kind {
+	case numberToken:
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", token.text)
+		}
+		return constantNode(value), nil
+	case nameToken:
+		// a function call, unless the name is quoted
+		if isFunctionCall(parser.tokens, parser.position-1) {
+			parser.position++
+			return parser.parseCall(token.text)
+		}
+		return columnNode(token.text), nil
+	default:
+		if token.text == "(" {
+			node, err := parser.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := parser.accept(")"); !ok {
+				return nil, fmt.Errorf("missing )")
+			}
+			return node, nil
+		}
+		return nil, fmt.Errorf("unexpected %s", token.text)
+	}
+}
+
+// parseCall parses the arguments of a function, after the opening parenthesis
+func (parser *expressionParser) parseCall(name string) (expression, error) {
+	call := &callNode{name: name}
+	if _, ok := parser.accept(")"); !ok {
+		for {
+			argument, err := parser.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.arguments = append(call.arguments, argument)
+			if _, ok := parser.accept(","); ok {
+				continue
+			}
+			if _, ok := parser.accept(")"); !ok {
+				return nil, fmt.Errorf("missing ) after the arguments of %s", name)
+			}
+			break
+		}
+	}
+	arity := expressionFunctions[name]
+	if (arity > 0 && len(call.arguments) != arity) || (arity < 0 && len(call.arguments) < -arity) {
+		return nil, fmt.Errorf("wrong number of arguments for %s", name)
+	}
+	return call, nil
+}
+
+// number of arguments of the functions, negative for the minimum number of a variable number
+var expressionFunctions = map[string]int{
+	"min":  -1,
+	"max":  -1,
+	"abs":  1,
+	"sqrt": 1,
+	"log":  1,
+	"exp":  1,
+	"if":   3,
+}
+
+type constantNode float64
+
+func (node constantNode) eval(columns map[string]*Column, rows int) ([]float64, []bool) {
+	values := make([]float64, rows)
+	valid := make([]bool, rows)
+	for i := range values {
+		values[i] = float64(node)
+		valid[i] = true
+	}
+	return values, valid
+}
+
+func (node constantNode) columnNames(names []string) []string {
+	return names
+}
+
+type columnNode string
+
+func (node columnNode) eval(columns map[string]*Column, rows int) ([]float64, []bool) {
+	column := columns[string(node)]
+	return column.Floats, column.Valid
+}
+
+func (node columnNode) columnNames(names []string) []string {
+	return append(names, string(node))
+}
+
+type unaryNode struct {
+	operator string
+	operand  expression
+}
+
+func (node *unaryNode) eval(columns map[string]*Column, rows int) ([]float64, []bool) {
+	operand, valid := node.operand.eval(columns, rows)
+	values := make([]float64, rows)
+	for i := range values {
+		switch node.operator {
+		case "-":
+			values[i] = -operand[i]
+		case "!":
+			values[i] = truth(operand[i] == 0)
+		default:
+			values[i] = operand[i]
+		}
+	}
+	return values, valid
+}
+
+func (node *unaryNode) columnNames(names []string) []string {
+	return node.operand.columnNames(names)
+}
+
+type binaryNode struct {
+	operator    string
+	left, right expression
+}
+
+func (node *binaryNode) eval(columns map[string]*Column, rows int) ([]float64, []bool) {
+	left, leftValid := node.left.eval(columns, rows)
+	right, rightValid := node.right.eval(columns, rows)
+	values := make([]float64, rows)
+	valid := make([]bool, rows)
+	for i := range values {
+		valid[i] = leftValid[i] && rightValid[i]
+		if !valid[i] {
+			continue
+		}
+		a, b := left[i], right[i]
+		switch node.operator {
+		case "+":
+			values[i] = a + b
+		case "-":
+			values[i] = a - b
+		case "*":
+			values[i] = a * b
+		case "/":
+			values[i] = a / b
+		case "^":
+			values[i] = math.Pow(a, b)
+		case "<":
+			values[i] = truth(a < b)
+		case "<=":
+			values[i] = truth(a <= b)
+		case ">":
+			values[i] = truth(a > b)
+		case ">=":
+			values[i] = truth(a >= b)
+		case "==":
+			values[i] = truth(a == b)
+		case "!=":
+			values[i] = truth(a != b)
+		case "&&":
+			values[i] = truth(a != 0 && b != 0)
+		case "||":
+			values[i] = truth(a != 0 || b != 0)
+		}
+	}
+	return values, valid
+}
+
+func (node *binaryNode) columnNames(names []string) []string {
+	return node.right.columnNames(node.left.columnNames(names))
+}
+
+type callNode struct {
+	name      string
+	arguments []expression
+}
+
+func (node *callNode) eval(columns map[string]*Column, rows int) ([]float64, []bool) {
+	arguments := make([][]float64, len(node.arguments))
+	argumentsValid := make([][]bool, len(node.arguments))
+	for i, argument := range node.arguments {
+		arguments[i], argumentsValid[i] = argument.eval(columns, rows)
+	}
+	values := make([]float64, rows)
+	valid := make([]bool, rows)
+	for i := range values {
+		if node.name == "if" {
+			// only the selected branch needs a value
+			if !argumentsValid[0][i] {
+				continue
+			}
+			branch := 2
+			if arguments[0][i] != 0 {
+				branch = 1
+			}
+			values[i], valid[i] = arguments[branch][i], argumentsValid[branch][i]
+			continue
+		}
+		valid[i] = true
+		for _, argumentValid := range argumentsValid {
+			valid[i] = valid[i] && argumentValid[i]
+		}
+		if !valid[i] {
+			continue
+		}
+		switch node.name {
+		case "min":
+			values[i] = arguments[0][i]
+			for _, argument := range arguments[1:] {
+				values[i] = math.Min(values[i], argument[i])
+			}
+		case "max":
+			values[i] = arguments[0][i]
+			for _, argument := range arguments[1:] {
+				values[i] = math.Max(values[i], argument[i])
+			}
+		case "abs":
+			values[i] = math.Abs(arguments[0][i])
+		case "sqrt":
+			values[i] = math.Sqrt(arguments[0][i])
+		case "log":
+			values[i] = math.Log(arguments[0][i])
+		case "exp":
+			values[i] = math.Exp(arguments[0][i])
+		}
+	}
+	return values, valid
+}
+
+func (node *callNode) columnNames(names []string) []string {
+	for _, argument := range node.arguments {
+		names = argument.columnNames(names)
+	}
+	return names
+}
+
+// truth converts a condition to 1 or 0
+func truth(condition bool) float64 {
+	if condition {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package cropgraph
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRenameExpressionColumns(t *testing.T) {
+	rename := func(name string) string {
+		return map[string]string{"AGB": "abovegrDryM", "wp1": "WP 1", "log": "Log \"ln\""}[name]
+	}
+	for _, test := range []struct {
+		expression string
+		want       string
+	}{
+		{"AGB / 1000", `"abovegrDryM" / 1000`},
+		{"max(AGB, 'wp1') - log(AGB)", `max("abovegrDryM", "WP 1") - log("abovegrDryM")`},
+		// a quoted name is a column, even with the name of a function
+		{`"log" + 1`, `'Log "ln"' + 1`},
+		{"Yield*2", "Yield*2"},
+		// the parse error of a missing parenthesis remains
+		{"(AGB", `("abovegrDryM"`},
+		// an unterminated name is not renamed
+		{`"AGB`, `"AGB`},
+	} {
+		got := renameExpressionColumns(test.expression, func(name string) string {
+			if renamed := rename(name); renamed != "" {
+				return renamed
+			}
+			return name
+		})
+		if got != test.want {
+			t.Errorf("%s: found %s, want %s", test.expression, got, test.want)
+		}
+	}
+}
+
+// evaluate parses and evaluates the expression over the columns a = [1 2 4], b = [2 0 missing] and "W 1" = [3 3 3]
+func evaluate(t *testing.T, text string) (*Column, error) {
+	t.Helper()
+	b := NewFloatColumn("b", []float64{2, 0, 0})
+	b.Valid[2] = false
+	columns := map[string]*Column{
+		"a":   NewFloatColumn("a", []float64{1, 2, 4}),
+		"b":   b,
+		"W 1": NewFloatColumn("W 1", []float64{3, 3, 3}),
+		"c":   {Name: "c", Type: StringColumn, Strings: []string{"x", "y", "z"}, Valid: []bool{true, true, true}},
+	}
+	node, err := parseExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	return evaluateExpression(node, columns)
+}
+
+func TestEvaluateExpression(t *testing.T) {
+	// NaN marks a missing result
+	missing := math.NaN()
+	for _, test := range []struct {
+		expression string
+		want       []float64
+	}{
+		{"1 + 2 * 3", []float64{7, 7, 7}},
+		{"(1 + 2) * 3", []float64{9, 9, 9}},
+		{"10 - 4 - 3", []float64{3, 3, 3}},
+		{"2 ^ 3 ^ 2", []float64{512, 512, 512}},
+		{"-2 ^ 2", []float64{-4, -4, -4}},
+		{"2 ^ -1", []float64{0.5, 0.5, 0.5}},
+		{"--a", []float64{1, 2, 4}},
+		{"1.5e1 / 3", []float64{5, 5, 5}},
+		{`"W 1" * a`, []float64{3, 6, 12}},
+		{`'W 1' - a`, []float64{2, 1, -1}},
+		{"a > 1 && a < 4", []float64{0, 1, 0}},
+		{"a <= 1 || a == 4", []float64{1, 0, 1}},
+		{"!(a != 2)", []float64{0, 1, 0}},
+		{"if(a >= 2, a * 10, -a)", []float64{-1, 20, 40}},
+		{"min(a, 3, 2) + max(a, 3)", []float64{4, 5, 6}},
+		{"abs(1 - a) + sqrt(a)", []float64{1, 1 + math.Sqrt2, 5}},
+		{"log(exp(a))", []float64{1, 2, 4}},
+		// missing values and results, which are not finite numbers
+		{"a + b", []float64{3, 2, missing}},
+		{"a / b", []float64{0.5, missing, missing}},
+		{"sqrt(-a)", []float64{missing, missing, missing}},
+		{"log(a - 1)", []float64{missing, 0, math.Log(3)}},
+		// only the selected branch needs a value
+		{"if(a < 4, b, a)", []float64{2, 0, 4}},
+		{"if(b, a, 0)", []float64{1, 0, missing}},
+	} {
+		result, err := evaluate(t, test.expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+		for i, want := range test.want {
+			if math.IsNaN(want) != !result.Valid[i] || (result.Valid[i] && math.Abs(result.Floats[i]-want) > 1e-12) {
+				t.Errorf("%s: found %v %v, want %v", test.expression, result.Floats, result.Valid, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		want       string
+	}{
+		{"(a + 1", "missing )"},
+		{"a + 1)", "unexpected )"},
+		{"max(a, 1", "missing ) after the arguments of max"},
+		{"foo(a)", "unexpected ("},
+		{"sqrt(a, b)", "wrong number of arguments for sqrt"},
+		{"if(a, b)", "wrong number of arguments for if"},
+		{"min()", "wrong number of arguments for min"},
+		{"a +", "unexpected end of expression"},
+		{"a $ b", "unexpected character '$'"},
+		{`"W 1 + a`, "unterminated column name"},
+		{"a + d", "column d is not listed in the columns of the graph"},
+		{"c * 2", "column c is not numeric"},
+	} {
+		_, err := evaluate(t, test.expression)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: found %v, want %s", test.expression, err, test.want)
+		}
+	}
+}
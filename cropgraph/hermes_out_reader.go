@@ -1,7 +1,7 @@
 package cropgraph
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -9,21 +9,43 @@ import (
 	"path/filepath"
 	"slices"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/components"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
-// HermesCsvToGraph reads the hermes simulation output file and generates graphs as defined in the config file
+func init() {
+	RegisterDataSource("HermesCSVOut", hermesCSVSource{})
+}
+
+// hermesCSVSource reads hermes csv output files
+type hermesCSVSource struct{}
+
+func (hermesCSVSource) Read(inputFile string, config Config) (*SourceData, error) {
+	table, mappingColumnToIndex, err := ReadFileData(inputFile, config)
+	if err != nil {
+		return nil, err
+	}
+	return &SourceData{Table: table, ColumnIndex: mappingColumnToIndex}, nil
+}
+
+// HermesCsvToGraph reads the simulation output file and generates graphs as defined in the config file
+// the input file is read with the data source registered for config.InputType
 func HermesCsvToGraph(inputFile string, config *Config, outputFile string) error {
 
-	rowData, mappingColumnToIndex, err := ReadFileData(inputFile, *config)
+	data, err := ReadInputData(inputFile, *config)
 	if err != nil {
 		return err
 	}
 
+	events, err := detectEvents(config.Events, data.Table)
+	if err != nil {
+		return fmt.Errorf("%s: %w", inputFile, err)
+	}
+	printEvents(inputFile, events, firstDateColumn(config, data.Table), config.dateDisplayFormat())
+
 	// genrate a web page for the graph
 	page := MakePage()
 	// for each graph in the config file generate the graph
@@ -37,17 +59,24 @@ func HermesCsvToGraph(inputFile string, config *Config, outputFile string) error
 	slices.Sort(graphNames)
 
 	for _, graphName := range graphNames {
-		graph := config.ColumnToGraph[graphName]
-		// get all lists of values for the graph
-		values := make([][]interface{}, len(graph.Columns))
+		// expand the column patterns against the columns of the input file
+		graph, err := expandGraph(config.ColumnToGraph[graphName], data.Table.Names())
+		if err != nil {
+			return fmt.Errorf("%s: %w", inputFile, err)
+		}
+		// get all columns for the graph
+		values := make([]*Column, len(graph.Columns))
 		for i, column := range graph.Columns {
-			if _, ok := mappingColumnToIndex[column]; !ok {
-				return fmt.Errorf("column %s not found in the input file", column)
+			var ok bool
+			if values[i], ok = data.Table.Column(column); !ok {
+				return &ColumnNotFoundError{File: inputFile, Column: column}
 			}
-			values[i] = rowData[column]
 		}
 		// add the graph to the page
-		page = GenerateGraph(page, graph, config.Theme, config.DateFormat, values)
+		page, err = GenerateGraph(page, graph, config, values, events)
+		if err != nil {
+			return fmt.Errorf("%s: %w", inputFile, err)
+		}
 
 	}
 	// save the page to the output file
@@ -55,6 +84,8 @@ func HermesCsvToGraph(inputFile string, config *Config, outputFile string) error
 	return err
 }
 
+// BatchFileToGraph generates the graphs for each input file listed in the batch file
+// an input file that fails does not stop the batch, the errors of all failed files are returned together
 func BatchFileToGraph(batchFile string, configFile string) error {
 	// read config file
 	config, err := ReadConfigFile(configFile)
@@ -69,32 +100,47 @@ func BatchFileToGraph(batchFile string, configFile string) error {
 	}
 	defer file.Close()
 
-	// go csv reader
-	// https://golang.org/pkg/encoding/csv/
-	reader := csv.NewReader(file)
-	reader.Comma = rune(config.Delimiter[0])
+	delimiter := config.Delimiter
+	if delimiter == "" {
+		delimiter = ","
+		if lines, err := readSniffLines(batchFile); err == nil && len(lines) > 0 {
+			delimiter = sniffDelimiter(lines, NumberFormat{})
+		}
+	}
+	// the output file is optional for multiple files
+	reader, err := newRowReader(file, delimiter)
+	if err != nil {
+		return err
+	}
+
+	// errors of the failed input files
+	batchErrors := []error{}
 	if config.MultiFiles {
 		outToInputFile := map[string][]string{}
 		currentOUtputFile := ""
 		for {
 			// read the row
 			row, err := reader.Read()
-			if err != nil {
+			if err == io.EOF {
 				break
 			}
+			if err != nil {
+				return readError(batchFile, err)
+			}
+			line := reader.Line()
 			// read the input and output file from the batch file
 			if len(row) < 1 {
-				return fmt.Errorf("batch file must have at least an input file")
+				return &ParseError{File: batchFile, Line: line, Err: fmt.Errorf("batch file must have at least an input file")}
 			}
 
 			inputFile := row[0]
 			outputfile := currentOUtputFile
-			if row[1] != "" {
+			if len(row) > 1 && row[1] != "" {
 				outputfile = row[1]
 				currentOUtputFile = outputfile
 			}
 			if outputfile == "" {
-				return fmt.Errorf("output file must be given")
+				return &ParseError{File: batchFile, Line: line, Err: fmt.Errorf("output file must be given")}
 			}
 
 			if _, ok := outToInputFile[outputfile]; !ok {
@@ -103,36 +149,44 @@ func BatchFileToGraph(batchFile string, configFile string) error {
 			outToInputFile[outputfile] = append(outToInputFile[outputfile], inputFile)
 		}
 
-		for outputFile, inputFiles := range outToInputFile {
+		outputFiles := make([]string, 0, len(outToInputFile))
+		for outputFile := range outToInputFile {
+			outputFiles = append(outputFiles, outputFile)
+		}
+		slices.Sort(outputFiles)
+		for _, outputFile := range outputFiles {
 			// make graphs from multiple input files
-			err = MultiFileToGraph(inputFiles, config, outputFile)
+			err = MultiFileToGraph(outToInputFile[outputFile], config, outputFile)
 			if err != nil {
-				return err
+				batchErrors = append(batchErrors, fmt.Errorf("%s: %w", outputFile, err))
 			}
-
 		}
 
 	} else {
 		for {
 			// read the row
 			row, err := reader.Read()
-			if err != nil {
+			if err == io.EOF {
 				break
 			}
+			if err != nil {
+				return readError(batchFile, err)
+			}
+			line := reader.Line()
 			// read the input and output file from the batch file
 			if len(row) < 2 {
-				return fmt.Errorf("batch file must have at least two columns")
+				return &ParseError{File: batchFile, Line: line, Err: fmt.Errorf("batch file must have at least two columns")}
 			}
 			inputFile := row[0]
 			outputFile := row[1]
 			// generate the graph
 			err = HermesCsvToGraph(inputFile, config, outputFile)
 			if err != nil {
-				return err
+				batchErrors = append(batchErrors, fmt.Errorf("%s:%d: %w", batchFile, line, err))
 			}
 		}
 	}
-	return nil
+	return errors.Join(batchErrors...)
 }
 
 func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) error {
@@ -146,15 +200,28 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 	slices.Sort(graphNames)
 
 	// read all input files
-	rowDataList := make([]map[string][]interface{}, 0, len(inputFiles))
-	mappingColumnToIndexList := make([]map[string]int, 0, len(inputFiles))
+	tables := make([]*Table, 0, len(inputFiles))
+	// input file of each table
+	tableFiles := make([]string, 0, len(inputFiles))
+	// events of each table
+	tableEvents := make([][]Event, 0, len(inputFiles))
 	for _, inputFile := range inputFiles {
-		rowData, mappingColumnToIndex, err := ReadFileData(inputFile, *config)
+		// files with several simulation runs contribute one entry per run
+		runs, err := ReadInputRuns(inputFile, *config)
 		if err != nil {
 			return err
 		}
-		rowDataList = append(rowDataList, rowData)
-		mappingColumnToIndexList = append(mappingColumnToIndexList, mappingColumnToIndex)
+		for _, data := range runs {
+			// the events are listed for each run and marked on the kline graphs
+			events, err := detectEvents(config.Events, data.Table)
+			if err != nil {
+				return fmt.Errorf("%s: %w", inputFile, err)
+			}
+			printEvents(inputFile, events, firstDateColumn(config, data.Table), config.dateDisplayFormat())
+			tables = append(tables, data.Table)
+			tableFiles = append(tableFiles, inputFile)
+			tableEvents = append(tableEvents, events)
+		}
 	}
 
 	// genrate a web page for the graph
@@ -169,17 +236,20 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 	}
 
 	for _, graphName := range graphNames {
-		graph := config.ColumnToGraph[graphName]
+		graph, err := expandGraph(config.ColumnToGraph[graphName], tables[0].Names())
+		if err != nil {
+			return fmt.Errorf("%s: %w", tableFiles[0], err)
+		}
 		if graph.GraphType == "kline" {
 			// merge data for kline graph
 			// requires a list of (date, open, close, low, high) values
 			// number of columns must be 1 + date column
 			dates := []string{}
+			var dateColumn *Column
 			if graph.DateColumn != "" {
-				if col, ok := rowDataList[0][graph.DateColumn]; ok {
-					for _, date := range col {
-						dates = append(dates, date.(string))
-					}
+				if col, ok := tables[0].Column(graph.DateColumn); ok {
+					dateColumn = col
+					dates = col.FormatTimes(config.dateDisplayFormat())
 				}
 			}
 			if (len(dates) == 0 && len(graph.Columns) != 1) || (len(dates) > 0 && len(graph.Columns) != 2) {
@@ -196,17 +266,29 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 					break
 				}
 			}
+			// unit of the data column, shown as name of the y axis
+			unit := ""
+			if column, ok := tables[0].Column(columnName); ok {
+				unit = column.Unit
+			}
+			// valid values of all files by date
 			byDate := make([][]float64, numEntries)
 			for i := range byDate {
-				byDate[i] = make([]float64, len(rowDataList))
+				byDate[i] = make([]float64, 0, len(tables))
 			}
 
-			for i, rowData := range rowDataList {
-				if _, ok := mappingColumnToIndexList[i][columnName]; !ok {
-					return fmt.Errorf("column %s not found in the input file", columnName)
+			for i, table := range tables {
+				column, ok := table.Column(columnName)
+				if !ok {
+					return &ColumnNotFoundError{File: tableFiles[i], Column: columnName}
 				}
-				for j, value := range rowData[columnName] {
-					byDate[j][i] = AsFloat(value)
+				if column.Type != FloatColumn {
+					return fmt.Errorf("%s: column %s is not numeric", tableFiles[i], columnName)
+				}
+				for j := 0; j < column.Len() && j < numEntries; j++ {
+					if column.Valid[j] {
+						byDate[j] = append(byDate[j], column.Floats[j])
+					}
 				}
 			}
 			// calculate standard deviation and average
@@ -217,11 +299,8 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 				low[i] = math.MaxFloat64
 			}
 			high := make([]float64, numEntries)
-			numFiles := float64(len(rowDataList))
 			for i := range byDate {
-				if i == 309 {
-					fmt.Println("here")
-				}
+				numFiles := float64(len(byDate[i]))
 				for _, value := range byDate[i] {
 					if value < low[i] {
 						low[i] = value
@@ -236,6 +315,7 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 			// calculate standard deviation
 			stdDevs := make([]float64, numEntries)
 			for i := range byDate {
+				numFiles := float64(len(byDate[i]))
 				for _, value := range byDate[i] {
 					stdDevs[i] += (value - averages[i]) * (value - averages[i])
 				}
@@ -251,15 +331,44 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 					low:   low[i],
 					high:  high[i]})
 			}
-			kline := makeKline(graphStyle{title: graph.Title, theme: config.Theme, dateformat: config.DateFormat})
+			// events of all runs, at the dates of the kline entries
+			markLines := []eventMarkLine{}
+			for _, events := range tableEvents {
+				for _, markLine := range eventMarkLines(events, dateColumn, config.dateDisplayFormat()) {
+					if !slices.Contains(markLines, markLine) {
+						markLines = append(markLines, markLine)
+					}
+				}
+			}
+			kline := makeKline(graphStyle{title: graph.Title, theme: config.Theme, dateformat: config.dateDisplayFormat(), unit: unit})
 			klineEntriesOpt := make([]opts.KlineData, 0, len(klineEntries))
+			if hasTimeAxis(dateColumn) {
+				// [date, open, close, low, high] on a time axis, dates without values are left empty
+				kline.SetGlobalOptions(withTimeAxis())
+				for i := 0; i < len(klineEntries); i++ {
+					if len(byDate[i]) == 0 || !dateColumn.Valid[i] {
+						continue
+					}
+					val := []interface{}{dateColumn.Times[i].Format(timeAxisLayout),
+						klineEntries[i].open, klineEntries[i].close, klineEntries[i].low, klineEntries[i].high}
+					klineEntriesOpt = append(klineEntriesOpt, opts.KlineData{Value: val})
+				}
+				kline.AddSeries("kline", klineEntriesOpt, withEventMarkLines(markLines)...)
+				page = page.AddCharts(kline)
+				continue
+			}
 			for i := 0; i < len(klineEntries); i++ {
+				if len(byDate[i]) == 0 {
+					// no valid value for this date
+					klineEntriesOpt = append(klineEntriesOpt, opts.KlineData{Value: "-"})
+					continue
+				}
 				// entry to [4]float
 				val := []float64{klineEntries[i].open, klineEntries[i].close, klineEntries[i].low, klineEntries[i].high}
 				klineEntriesOpt = append(klineEntriesOpt, opts.KlineData{Value: val})
 			}
 
-			kline.SetXAxis(dates).AddSeries("kline", klineEntriesOpt)
+			kline.SetXAxis(dates).AddSeries("kline", klineEntriesOpt, withEventMarkLines(markLines)...)
 			page = page.AddCharts(kline)
 
 		}
@@ -270,7 +379,8 @@ func MultiFileToGraph(inputFiles []string, config *Config, outputFile string) er
 	return err
 }
 
-func ReadFileData(inputFile string, config Config) (map[string][]interface{}, map[string]int, error) {
+// ReadFileData reads the selected columns of a hermes csv output file into a typed table
+func ReadFileData(inputFile string, config Config) (*Table, map[string]int, error) {
 	// Read the hermes simulation output file
 	file, err := os.Open(inputFile)
 	if err != nil {
@@ -278,58 +388,114 @@ func ReadFileData(inputFile string, config Config) (map[string][]interface{}, ma
 	}
 	defer file.Close()
 
-	// go csv reader
-	// https://golang.org/pkg/encoding/csv/
-	reader := csv.NewReader(file)
-	reader.Comma = rune(config.Delimiter[0])
+	// infer the input format, if not given in the config
+	config, err = sniffMissingFormat(inputFile, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	// header lines may have a different number of fields, data rows are checked below
+	reader, err := newRowReader(file, config.Delimiter)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// list all required columns from the config file
-	configColumns := map[string]int{}
-	for _, graph := range config.ColumnToGraph {
-		for _, column := range graph.Columns {
-			configColumns[column] = -1
+	configColumns := requiredColumns(config)
+	layouts, err := config.dateLayouts()
+	if err != nil {
+		return nil, nil, err
+	}
+	builder := newTableBuilder(inputFile, config, layouts...)
+	if len(layouts) > 0 {
+		for colName := range dateColumns(config) {
+			builder.SetDateFormat(colName, layouts...)
 		}
 	}
 	// map column name to index in the csv file
 	mappingColumnToIndex := map[string]int{}
+	numColumns := 0
+
+	// appends a data row to the table
+	addRow := func(row []string) error {
+		line := reader.Line()
+		if numColumns > 0 && len(row) != numColumns {
+			return &ParseError{File: inputFile, Line: line,
+				Err: fmt.Errorf("expected %d values, found %d", numColumns, len(row))}
+		}
+		builder.StartRow(line)
+		// for each column in the row check if it is listed in the config file
+		// if yes, store the value to later generate a graph
+		for colName, colIndex := range mappingColumnToIndex {
+			// read the value of the selected column
+			// and store it in the crop graph
+			builder.Append(colName, row[colIndex])
+		}
+		return nil
+	}
 
 	// read number of header, as defined in the config file
+	// header lines after the column names, which are data rows, are read as data
+	isData := false
 	for i := 0; i < config.NumHeader; i++ {
 
 		col, err := reader.Read()
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, readError(inputFile, err)
 		}
 		// for the first header line
 		if i == 0 {
+			numColumns = len(col)
 			for colIndex, colName := range col {
-				// if column is listed in the config file
-				if _, ok := configColumns[colName]; ok {
+				// if column is listed in the config file, by name or alias
+				if colName, ok := configColumns.Resolve(colName); ok {
 					// store the index of the column
 					mappingColumnToIndex[colName] = colIndex
+					builder.AddColumn(colName)
+				}
+			}
+			continue
+		}
+		if !isData && isDataRow(col, config.NumberFormat(), config.NATokens) {
+			isData = true
+			fmt.Println("Warnung", inputFile, "header line", i+1, "is a data row, read as data instead of", config.NumHeader, "header lines")
+		}
+		if isData {
+			if err := addRow(col); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		// the second header line holds the units
+		if i == 1 {
+			for colName, colIndex := range mappingColumnToIndex {
+				if colIndex < len(col) {
+					builder.SetUnit(colName, col[colIndex])
 				}
 			}
 		}
 	}
 
-	rowData := map[string][]interface{}{}
 	// read data from rows after the header
 	for {
 		// read the row
 		row, err := reader.Read()
-		if err != nil {
+		if err == io.EOF {
 			break
 		}
-		// for each column in the row check if it is listed in the config file
-		// if yes, store the value to later generate a graph
-		for colName, colIndex := range mappingColumnToIndex {
-			// read the value of the selected column
-			// and store it in the crop graph
-			rowData[colName] = append(rowData[colName], row[colIndex])
+		if err != nil {
+			return nil, nil, readError(inputFile, err)
+		}
+		if err := addRow(row); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	return rowData, mappingColumnToIndex, nil
+	// parse the values into typed columns
+	table, err := builder.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, mappingColumnToIndex, nil
 }
 
 // MakePage creates a new web page
@@ -361,37 +527,44 @@ func SavePage(page *components.Page, outfile string) error {
 }
 
 // graph generation
-func GenerateGraph(page *components.Page, graphType GraphDefinition, theme, dateformat string, values [][]interface{}) *components.Page {
+// the events of the input file are marked on line and bar graphs
+func GenerateGraph(page *components.Page, graphType GraphDefinition, config *Config, values []*Column, events []Event) (*components.Page, error) {
 	outPage := page
 	// generate the graph
 	if len(values) == 0 {
-		return outPage
+		return outPage, nil
 	}
 	// extract keys from the first column
 	keys := extractKeys(values[0])
 	var dates []string = nil
+	var dateColumn *Column = nil
 	// create dates if the date column is given
 	if graphType.DateColumn != "" {
 		for i, column := range graphType.Columns {
 			if column == graphType.DateColumn {
 				// convert dates to string
-				dates = make([]string, len(keys))
-				for j, date := range values[i] {
-					dates[j] = date.(string)
-				}
+				dateColumn = values[i]
+				dates = dateColumn.FormatTimes(config.dateDisplayFormat())
 			}
 		}
 	}
+	// positions of the events, taken before the rows are resampled
+	markLines := eventMarkLines(events, dateColumn, config.dateDisplayFormat())
 
 	var columns []string
-	var combinedColumnValues [][]interface{}
+	var combinedColumnValues []*Column
 	if graphType.ColumnView != nil {
-		combinedColumnValues = make([][]interface{}, 0, len(graphType.ColumnView))
+		combinedColumnValues = make([]*Column, 0, len(graphType.ColumnView))
 		columns = make([]string, 0, len(graphType.ColumnView))
+		// operations may refer to the date column and the other columns of the graph
+		context := OperationContext{Dates: dateColumn, Columns: map[string]*Column{}}
+		for i, column := range graphType.Columns {
+			context.Columns[column] = values[i]
+		}
 		// apply operations to the columns
 		for _, operationDefinition := range graphType.ColumnView {
-			// get the column values for the operation
-			columnValues := make([][]interface{}, len(operationDefinition.Columns))
+			// get the columns for the operation
+			columnValues := make([]*Column, len(operationDefinition.Columns))
 			for i, column := range operationDefinition.Columns {
 				for j, col := range graphType.Columns {
 					if col == column {
@@ -399,19 +572,32 @@ func GenerateGraph(page *components.Page, graphType GraphDefinition, theme, date
 						break
 					}
 				}
+				if columnValues[i] == nil {
+					return nil, fmt.Errorf("column %s of operation %s is not listed in the columns of graph %s",
+						column, operationDefinition.Name, graphType.Title)
+				}
 			}
 			// apply the operation to the column values
-			newColumnValues := HandleColumnViewOperation(operationDefinition, columnValues)
-			combinedColumnValues = append(combinedColumnValues, newColumnValues)
+			if config.MissingValues != "" {
+				operationDefinition = withDefaultParameter(operationDefinition, "missing", config.MissingValues)
+			}
+			newColumn, err := HandleColumnViewOperation(operationDefinition, columnValues, context)
+			if err != nil {
+				return nil, fmt.Errorf("graph %s: %w", graphType.Title, err)
+			}
+			combinedColumnValues = append(combinedColumnValues, newColumn)
 			columns = append(columns, operationDefinition.Name)
 		}
 	} else {
 		combinedColumnValues = values
+		columns = graphType.Columns
 		if graphType.DateColumn != "" {
 			for i, column := range graphType.Columns {
 				if column == graphType.DateColumn {
 					// remove date column from columns
-					combinedColumnValues = append(combinedColumnValues[:i], combinedColumnValues[i+1:]...)
+					combinedColumnValues = make([]*Column, 0, len(values))
+					combinedColumnValues = append(combinedColumnValues, values[:i]...)
+					combinedColumnValues = append(combinedColumnValues, values[i+1:]...)
 					columns = make([]string, 0, len(graphType.Columns))
 					columns = append(columns, graphType.Columns[:i]...)
 					columns = append(columns, graphType.Columns[i+1:]...)
@@ -420,79 +606,161 @@ func GenerateGraph(page *components.Page, graphType GraphDefinition, theme, date
 			}
 		}
 	}
-	// graph style
-	graphStyle := graphStyle{
-		title:      graphType.Title,
-		theme:      theme,
-		dateformat: dateformat,
+	// aggregate the series to periods, after the operations on the rows of the input file
+	if graphType.Resample != nil {
+		var err error
+		dateColumn, combinedColumnValues, err = resample(*graphType.Resample, dateColumn, combinedColumnValues, columns, config.Catalog)
+		if err != nil {
+			return nil, fmt.Errorf("graph %s: %w", graphType.Title, err)
+		}
+		keys = extractKeys(dateColumn)
+		dates = dateColumn.FormatTimes(config.dateDisplayFormat())
 	}
+	// columns of different units are drawn in one graph per unit, if requested
+	groups := groupByUnit(combinedColumnValues)
+	if len(groups) > 1 && !graphType.SplitUnits {
+		fmt.Println("Warnung", "graph", graphType.Title, "mixes the units", strings.Join(groupUnits(combinedColumnValues, groups), ", "),
+			"(set splitunits to draw one graph per unit)")
+		groups = [][]int{allIndices(len(combinedColumnValues))}
+	}
+	for _, group := range groups {
+		groupColumns := make([]string, 0, len(group))
+		groupValues := make([]*Column, 0, len(group))
+		// descriptions of the catalog in the order of the series
+		descriptions := make([]string, 0, len(group))
+		for _, i := range group {
+			// the label of the catalog and the unit are shown in legends and tooltips
+			entry, _ := config.Catalog.Lookup(columns[i])
+			descriptions = append(descriptions, entry.Description)
+			groupColumns = append(groupColumns, seriesName(config.Catalog.Label(columns[i]), combinedColumnValues[i].Unit))
+			groupValues = append(groupValues, combinedColumnValues[i])
+		}
+		// graph style
+		graphStyle := graphStyle{
+			title:        graphType.Title,
+			theme:        config.Theme,
+			dateformat:   config.dateDisplayFormat(),
+			connectNulls: config.ConnectNulls,
+			unit:         commonUnit(groupValues),
+			descriptions: descriptions,
+			markLines:    markLines,
+		}
+		if len(groups) > 1 && graphStyle.unit != "" {
+			graphStyle.title = seriesName(graphType.Title, graphStyle.unit)
+		}
 
-	switch graphType.GraphType {
-	case "line":
-		outPage = page.AddCharts(
-			lineMultiData(keys, dates, graphStyle, columns, combinedColumnValues),
-		)
-	case "ThemeRiver":
-		outPage = page.AddCharts(
-			themeRiverMultiData(keys, dates, graphStyle, columns, combinedColumnValues),
-		)
-	case "bar3d":
-		fmt.Println("Warnung", graphType.GraphType, "is kind of buggy. It will temper with the theme and rendering.")
-		outPage = page.AddCharts(
-			Bar3D(keys, dates, graphStyle, columns, combinedColumnValues),
-		)
-	default:
-		fmt.Println("Graph type ", graphType.GraphType, " not supported")
+		switch graphType.GraphType {
+		case "line":
+			outPage = page.AddCharts(
+				lineMultiData(keys, dateColumn, graphStyle, groupColumns, groupValues),
+			)
+		case "bar":
+			outPage = page.AddCharts(
+				barMultiData(keys, dateColumn, graphStyle, groupColumns, groupValues),
+			)
+		case "ThemeRiver":
+			outPage = page.AddCharts(
+				themeRiverMultiData(keys, dateColumn, graphStyle, groupColumns, groupValues),
+			)
+		case "bar3d":
+			fmt.Println("Warnung", graphType.GraphType, "is kind of buggy. It will temper with the theme and rendering.")
+			outPage = page.AddCharts(
+				Bar3D(keys, dates, graphStyle, groupColumns, groupValues),
+			)
+		default:
+			fmt.Println("Graph type ", graphType.GraphType, " not supported")
+		}
 	}
 
-	return outPage
+	return outPage, nil
 }
 
 type graphStyle struct {
 	title      string
 	theme      string
 	dateformat string
+	// connect line series across missing values
+	connectNulls bool
+	// unit of all series, shown as name of the y axis
+	unit string
+	// descriptions of the series, shown in tooltips
+	descriptions []string
+	// events, drawn as vertical lines
+	markLines []eventMarkLine
 }
 
-func extractKeys(valueList []interface{}) []int {
-	keys := make([]int, 0, len(valueList))
-	for i := range valueList {
+func extractKeys(column *Column) []int {
+	keys := make([]int, 0, column.Len())
+	for i := 0; i < column.Len(); i++ {
 		keys = append(keys, i)
 	}
 	return keys
 }
 
-func lineMultiData(keys []int, dates []string, graphStyle graphStyle, columns []string, values [][]interface{}) *charts.Line {
+func lineMultiData(keys []int, dateColumn *Column, graphStyle graphStyle, columns []string, values []*Column) *charts.Line {
 
 	line := makeMultiLine(graphStyle)
 
-	if dates == nil {
-		dates = make([]string, len(keys))
-		for i, key := range keys {
-			dates[i] = strconv.Itoa(key)
+	lineOpts := charts.WithLineChartOpts(opts.LineChart{ConnectNulls: graphStyle.connectNulls})
+	if hasTimeAxis(dateColumn) {
+		// dates on a time axis, skipped dates are shown as gaps
+		line.SetGlobalOptions(withTimeAxis())
+		for i, column := range columns {
+			line.AddSeries(column, generateTimeItems(dateColumn, values[i]), lineOpts)
 		}
+		markEvents(line.MultiSeries, graphStyle.markLines)
+		return line
 	}
-	graph := line.SetXAxis(dates)
+
+	graph := line.SetXAxis(categoryDates(keys, dateColumn, graphStyle))
 	for i, column := range columns {
-		graph = graph.AddSeries(column, generateItems(keys, values[i]))
+		graph = graph.AddSeries(column, generateItems(keys, values[i]), lineOpts)
 	}
+	markEvents(line.MultiSeries, graphStyle.markLines)
 	return line
 }
 
-func themeRiverMultiData(keys []int, dates []string, graphStyle graphStyle, columns []string, values [][]interface{}) *charts.ThemeRiver {
-	themeRiver := makeThemeRiver(graphStyle)
-	if dates == nil {
-		dates = make([]string, len(keys))
-		for i, key := range keys {
-			dates[i] = strconv.Itoa(key)
+func barMultiData(keys []int, dateColumn *Column, graphStyle graphStyle, columns []string, values []*Column) *charts.Bar {
+
+	bar := makeMultiBar(graphStyle)
+
+	if hasTimeAxis(dateColumn) {
+		bar.SetGlobalOptions(withTimeAxis())
+		for i, column := range columns {
+			bar.AddSeries(column, generateTimeBarItems(dateColumn, values[i]))
 		}
+		markEvents(bar.MultiSeries, graphStyle.markLines)
+		return bar
+	}
+
+	graph := bar.SetXAxis(categoryDates(keys, dateColumn, graphStyle))
+	for i, column := range columns {
+		graph = graph.AddSeries(column, generateBarItems(keys, values[i]))
 	}
+	markEvents(bar.MultiSeries, graphStyle.markLines)
+	return bar
+}
 
-	themeRiver.AddSeries("themeRiver", generateItemTripple(graphStyle.dateformat, dates, values, columns))
+// categoryDates returns the labels of a category axis, the formatted dates or the row numbers
+func categoryDates(keys []int, dateColumn *Column, graphStyle graphStyle) []string {
+	if dateColumn != nil {
+		return dateColumn.FormatTimes(graphStyle.dateformat)
+	}
+	dates := make([]string, len(keys))
+	for i, key := range keys {
+		dates[i] = strconv.Itoa(key)
+	}
+	return dates
+}
+
+func themeRiverMultiData(keys []int, dateColumn *Column, graphStyle graphStyle, columns []string, values []*Column) *charts.ThemeRiver {
+	themeRiver := makeThemeRiver(graphStyle)
+
+	themeRiver.AddSeries("themeRiver", generateItemTripple(keys, dateColumn, values, columns))
 	return themeRiver
 }
 
-func Bar3D(keys []int, dates []string, graphStyle graphStyle, columns []string, values [][]interface{}) *charts.Bar3D {
+func Bar3D(keys []int, dates []string, graphStyle graphStyle, columns []string, values []*Column) *charts.Bar3D {
 	bar3d := makebar3DShading(graphStyle)
 
 	if dates == nil {
@@ -509,35 +777,42 @@ func Bar3D(keys []int, dates []string, graphStyle graphStyle, columns []string,
 	bar3d.AddSeries("bar3d", generateItemBar3D(dates, values, columns), charts.WithBar3DChartOpts(opts.Bar3DChart{Shading: "lambert"}))
 	return bar3d
 }
-func generateItemBar3D(dates []string, values [][]interface{}, columns []string) []opts.Chart3DData {
+func generateItemBar3D(dates []string, values []*Column, columns []string) []opts.Chart3DData {
 
 	items := make([]opts.Chart3DData, 0, len(dates)*len(columns))
 
 	for i := range columns {
 		for j := range dates {
 			items = append(items, opts.Chart3DData{
-				Value: []interface{}{j, i, values[i][j]}, // {x, y, z}
+				Value: []interface{}{j, i, values[i].Value(j)}, // {x, y, z}
 			})
 		}
 	}
 	return items
 }
 
-func generateItemTripple(dateFormatConfig string, dates []string, values [][]interface{}, columns []string) []opts.ThemeRiverData {
+func generateItemTripple(keys []int, dateColumn *Column, values []*Column, columns []string) []opts.ThemeRiverData {
 
-	items := make([]opts.ThemeRiverData, 0, len(dates)*len(columns))
+	items := make([]opts.ThemeRiverData, 0, len(keys)*len(columns))
 
 	for i, column := range columns {
-		for j, date := range dates {
+		if values[i].Type != FloatColumn {
+			continue
+		}
+		for _, j := range keys {
 			// {"2015/11/28", 10, "DD"},
-			// convert current date string to new date format
-			dateTime, _ := time.Parse(dateFormatConfig, date)
-			dateFormated := dateTime.Format("2006/01/02")
+			// a theme river can not show missing values
+			if !values[i].Valid[j] {
+				continue
+			}
+			dateFormated := strconv.Itoa(j)
+			if dateColumn != nil && dateColumn.Type == TimeColumn && dateColumn.Valid[j] {
+				dateFormated = dateColumn.Times[j].Format("2006/01/02")
+			}
 
-			valueAsFloat := AsFloat(values[i][j])
 			items = append(items, opts.ThemeRiverData{
 				Date:  dateFormated,
-				Value: valueAsFloat,
+				Value: values[i].Floats[j],
 				Name:  column,
 			})
 		}
@@ -545,17 +820,53 @@ func generateItemTripple(dateFormatConfig string, dates []string, values [][]int
 	return items
 }
 
-func generateItems(keys []int, values []interface{}) []opts.LineData {
+func generateItems(keys []int, values *Column) []opts.LineData {
 
 	items := make([]opts.LineData, 0, len(keys))
 
 	for _, key := range keys {
-		val := values[key]
+		val := values.Value(key)
+		if val == nil {
+			// echarts shows missing values as gaps
+			val = "-"
+		}
 		items = append(items, opts.LineData{Value: val})
 	}
 	return items
 }
 
+func generateBarItems(keys []int, values *Column) []opts.BarData {
+
+	items := make([]opts.BarData, 0, len(keys))
+
+	for _, key := range keys {
+		val := values.Value(key)
+		if val == nil {
+			val = "-"
+		}
+		items = append(items, opts.BarData{Value: val})
+	}
+	return items
+}
+
+func generateTimeItems(dateColumn *Column, values *Column) []opts.LineData {
+	pairs := timeValues(dateColumn, values)
+	items := make([]opts.LineData, 0, len(pairs))
+	for _, pair := range pairs {
+		items = append(items, opts.LineData{Value: pair})
+	}
+	return items
+}
+
+func generateTimeBarItems(dateColumn *Column, values *Column) []opts.BarData {
+	pairs := timeValues(dateColumn, values)
+	items := make([]opts.BarData, 0, len(pairs))
+	for _, pair := range pairs {
+		items = append(items, opts.BarData{Value: pair})
+	}
+	return items
+}
+
 func makeThemeRiver(graphStyle graphStyle) *charts.ThemeRiver {
 	themeRiver := charts.NewThemeRiver()
 	themeRiver.SetGlobalOptions(
@@ -606,6 +917,10 @@ func makeMultiLine(graphStyle graphStyle) *charts.Line {
 			Top:   "5%",
 			Align: "left",
 		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: graphStyle.unit,
+		}),
+		charts.WithTooltipOpts(descriptionTooltip(graphStyle.descriptions)),
 		charts.WithDataZoomOpts(opts.DataZoom{
 			Type:       "inside",
 			Start:      0,
@@ -622,6 +937,40 @@ func makeMultiLine(graphStyle graphStyle) *charts.Line {
 	return line
 }
 
+func makeMultiBar(graphStyle graphStyle) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title: graphStyle.title,
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Theme: graphStyle.theme,
+		}),
+		charts.WithLegendOpts(opts.Legend{Show: true,
+			Right: "15%",
+			Top:   "5%",
+			Align: "left",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: graphStyle.unit,
+		}),
+		charts.WithTooltipOpts(descriptionTooltip(graphStyle.descriptions)),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:       "inside",
+			Start:      0,
+			End:        100,
+			XAxisIndex: []int{0},
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:       "slider",
+			Start:      0,
+			End:        100,
+			XAxisIndex: []int{0},
+		}),
+	)
+	return bar
+}
+
 func makebar3DShading(graphStyle graphStyle) *charts.Bar3D {
 	bar3d := charts.NewBar3D()
 	bar3DRangeColor := []string{
@@ -660,6 +1009,7 @@ func makeKline(graphStyle graphStyle) *charts.Kline {
 			SplitNumber: 20,
 		}),
 		charts.WithYAxisOpts(opts.YAxis{
+			Name:  graphStyle.unit,
 			Scale: true,
 		}),
 		charts.WithDataZoomOpts(opts.DataZoom{
@@ -0,0 +1,41 @@
+package cropgraph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadHeaderDataRow(t *testing.T) {
+	config, err := ReadConfigFile("../test_data/config/multi.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _, err := ReadFileData("../test_data/V-test-00001.csv", *config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, _ := table.Column("Date")
+	if table.Len() != 450 || !date.Times[0].Equal(time.Date(2022, 7, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("found %d rows from %v, want 450 rows from 2022-07-08", table.Len(), date.Times[0])
+	}
+}
+
+func TestReadUnitsRow(t *testing.T) {
+	config, err := ReadConfigFile(writeConfig(t, `
+numheader: 2
+columntograph:
+  g: {graphtype: line, title: LAI, columns: [Date, LAI], datecolumn: Date}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _, err := ReadFileData(writeInput(t, "input.csv", "Date,LAI\ndd.mm.yyyy,[m2/m2]\n08.07.2022,0.5\n"), *config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, _ := table.Column("Date")
+	lai, _ := table.Column("LAI")
+	if table.Len() != 1 || date.Unit != "" || lai.Unit != "m2/m2" {
+		t.Errorf("found %d rows, date unit %q, lai unit %q", table.Len(), date.Unit, lai.Unit)
+	}
+}
@@ -0,0 +1,165 @@
+package cropgraph
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterDataSource("MonicaCSV", monicaCSVSource{})
+}
+
+// monicaCSVSource reads monica csv output files
+//
+// a monica output section starts with a header block:
+//   - an optional output id row (e.g. "daily")
+//   - the column names
+//   - an optional units row
+//   - an optional aggregation row (e.g. AVG, SUM, LAST)
+//
+// followed by the data rows. Only the first section of a file is read.
+// Dates are written in ISO format (2006-01-02).
+type monicaCSVSource struct{}
+
+func (monicaCSVSource) Read(inputFile string, config Config) (*SourceData, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	delimiter := config.Delimiter
+	if delimiter == "" {
+		result, err := SniffInputFormat(inputFile, config)
+		if err != nil {
+			return nil, err
+		}
+		delimiter = result.Delimiter
+	}
+	// the output id row has less fields than the data rows
+	reader, err := newRowReader(file, delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	configColumns := requiredColumns(config)
+	numberFormat := config.NumberFormat()
+	builder := newTableBuilder(inputFile, config, isoDateFormat)
+	for colName := range dateColumns(config) {
+		builder.SetDateFormat(colName, isoDateFormat)
+	}
+
+	// header block
+	row, err := reader.Read()
+	if err != nil {
+		return nil, readError(inputFile, fmt.Errorf("missing monica header: %w", err))
+	}
+	if isMonicaOutputID(row) {
+		row, err = reader.Read()
+		if err != nil {
+			return nil, readError(inputFile, fmt.Errorf("missing monica column names: %w", err))
+		}
+	}
+	mappingColumnToIndex := map[string]int{}
+	for colIndex, colName := range row {
+		colName = strings.TrimSpace(colName)
+		if colName, ok := configColumns.Resolve(colName); ok {
+			mappingColumnToIndex[colName] = colIndex
+			builder.AddColumn(colName)
+		}
+	}
+
+	numColumns := len(row)
+	numHeaderRows := 0
+	numDataRows := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, readError(inputFile, err)
+		}
+		if !isMonicaDataRow(row, numColumns, numberFormat, config.NATokens) {
+			if numDataRows > 0 {
+				// start of the next output section
+				break
+			}
+			// the units row follows the column names, the aggregation row is not needed for the graphs
+			if numHeaderRows == 0 && !isMonicaAggregationRow(row) {
+				for colName, colIndex := range mappingColumnToIndex {
+					if colIndex < len(row) && strings.TrimSpace(row[colIndex]) != "" {
+						builder.SetUnit(colName, strings.TrimSpace(row[colIndex]))
+					}
+				}
+			}
+			numHeaderRows++
+			continue
+		}
+
+		numDataRows++
+		builder.StartRow(reader.Line())
+		for colName, colIndex := range mappingColumnToIndex {
+			value := ""
+			if colIndex < len(row) {
+				value = row[colIndex]
+			}
+			builder.Append(colName, value)
+		}
+	}
+
+	table, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &SourceData{Table: table, ColumnIndex: mappingColumnToIndex}, nil
+}
+
+// isMonicaOutputID checks if the row is the output id row of a monica section
+func isMonicaOutputID(row []string) bool {
+	numFields := 0
+	for _, field := range row {
+		if strings.TrimSpace(field) != "" {
+			numFields++
+		}
+	}
+	return numFields == 1 && len(row) <= 2
+}
+
+// monica aggregations of the output values
+var monicaAggregations = map[string]bool{
+	"AVG": true, "MEDIAN": true, "SUM": true, "MIN": true, "MAX": true, "FIRST": true, "LAST": true, "NONE": true,
+}
+
+// isMonicaAggregationRow checks if the non-empty fields of the row name aggregations, e.g. AVG or [1, 3, SUM]
+func isMonicaAggregationRow(row []string) bool {
+	numAggregations := 0
+	for _, field := range row {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		words := strings.FieldsFunc(strings.ToUpper(field), func(r rune) bool {
+			return r < 'A' || r > 'Z'
+		})
+		if !slices.ContainsFunc(words, func(word string) bool { return monicaAggregations[word] }) {
+			return false
+		}
+		numAggregations++
+	}
+	return numAggregations > 0
+}
+
+// isMonicaDataRow checks if the row holds an ISO date, or has a value for each column
+// and mostly numbers, text columns like the crop name are allowed
+func isMonicaDataRow(row []string, numColumns int, numberFormat NumberFormat, naTokens []string) bool {
+	for _, field := range row {
+		if _, err := time.Parse(isoDateFormat, strings.TrimSpace(field)); err == nil {
+			return true
+		}
+	}
+	return len(row) == numColumns && !isMonicaAggregationRow(row) && isDataRow(row, numberFormat, naTokens)
+}
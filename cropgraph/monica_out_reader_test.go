@@ -0,0 +1,50 @@
+package cropgraph
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMonicaReader(t *testing.T) {
+	for _, test := range []struct {
+		file    string
+		columns []string
+		rows    int
+		units   map[string]string
+		floats  map[string][]float64
+	}{
+		{"../test_data/monica/daily.csv", []string{"Crop", "Date", "Yield", "LAI"}, 3,
+			map[string]string{"Crop": "", "Yield": "kg ha-1", "LAI": "m2 m-2"},
+			map[string][]float64{"LAI": {0.1, 0.2, 0.4}}},
+		// no units row, the aggregation row is no unit
+		{"../test_data/monica/yearly.csv", []string{"Crop", "Year", "Precip"}, 2,
+			map[string]string{"Year": "", "Precip": ""},
+			map[string][]float64{"Year": {2020, 2021}, "Precip": {612.5, 540}}},
+	} {
+		t.Run(filepath.Base(test.file), func(t *testing.T) {
+			config := Config{InputType: "MonicaCSV", NATokens: []string{"NA"},
+				ColumnToGraph: map[string]GraphDefinition{"g": {GraphType: "line", Columns: test.columns}}}
+			data, err := ReadInputData(test.file, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.Table.Len() != test.rows {
+				t.Errorf("found %d rows, want %d", data.Table.Len(), test.rows)
+			}
+			for name, unit := range test.units {
+				if column, _ := data.Table.Column(name); column.Unit != unit {
+					t.Errorf("column %s: found unit %q, want %q", name, column.Unit, unit)
+				}
+			}
+			for name, values := range test.floats {
+				if column, _ := data.Table.Column(name); !slices.Equal(column.Floats, values) {
+					t.Errorf("column %s: found %v, want %v", name, column.Floats, values)
+				}
+			}
+			if crop, _ := data.Table.Column("Crop"); crop.Type != StringColumn || crop.Strings[0] == "" {
+				t.Errorf("crop column: found %+v", crop)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package cropgraph
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decimal number with optional exponent, after removing the thousands separator and replacing the decimal separator,
+// hexadecimal numbers, underscores, Inf and NaN are not numbers of an input file
+var plainNumber = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// ErrNumberOverflow reports a fortran overflow marker (e.g. *****),
+// written instead of a number that does not fit into its field width
+var ErrNumberOverflow = errors.New("number overflow marker")
+
+// NumberFormat describes how numbers are written in an input file
+type NumberFormat struct {
+	// decimal separator, "." if empty
+	DecimalSeparator string
+	// thousands separator, empty if numbers are not grouped
+	ThousandsSeparator string
+}
+
+// Parse parses a decimal number written in the number format
+// surrounding whitespace is ignored, fortran exponents (1.0D+03) are accepted
+// and fortran overflow markers return ErrNumberOverflow
+func (format NumberFormat) Parse(value string) (float64, error) {
+	number := strings.TrimSpace(value)
+	if isOverflowMarker(number) {
+		return 0, ErrNumberOverflow
+	}
+	if format.ThousandsSeparator != "" {
+		number = strings.ReplaceAll(number, format.ThousandsSeparator, "")
+	}
+	if format.DecimalSeparator != "" && format.DecimalSeparator != "." {
+		// only the configured decimal separator is accepted
+		if strings.Contains(number, ".") {
+			return 0, &strconv.NumError{Func: "ParseFloat", Num: value, Err: strconv.ErrSyntax}
+		}
+		number = strings.Replace(number, format.DecimalSeparator, ".", 1)
+	}
+	if strings.ContainsAny(number, "dD") {
+		number = strings.NewReplacer("d", "e", "D", "E").Replace(number)
+	}
+	if !plainNumber.MatchString(number) {
+		return 0, &strconv.NumError{Func: "ParseFloat", Num: value, Err: strconv.ErrSyntax}
+	}
+	return strconv.ParseFloat(number, 64)
+}
+
+// isOverflowMarker checks if the value is a fortran overflow marker, a field filled with asterisks
+func isOverflowMarker(value string) bool {
+	return strings.Contains(value, "*") && strings.Trim(value, "*+-") == ""
+}
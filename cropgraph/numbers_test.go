@@ -0,0 +1,60 @@
+package cropgraph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNumberFormatParse(t *testing.T) {
+	german := NumberFormat{DecimalSeparator: ",", ThousandsSeparator: "."}
+	for _, test := range []struct {
+		format NumberFormat
+		value  string
+		want   float64
+		ok     bool
+	}{
+		{NumberFormat{}, " 1.5 ", 1.5, true},
+		{NumberFormat{}, "-.5", -0.5, true},
+		{NumberFormat{}, "1.0D+03", 1000, true},
+		{NumberFormat{}, "2.5e-1", 0.25, true},
+		{NumberFormat{DecimalSeparator: "."}, "7.", 7, true},
+		{NumberFormat{ThousandsSeparator: ","}, "1,234.5", 1234.5, true},
+		{german, "1.234,5", 1234.5, true},
+		{german, "0,25", 0.25, true},
+		{NumberFormat{DecimalSeparator: ","}, "1.234", 0, false},
+		{NumberFormat{}, "0x1p3", 0, false},
+		{NumberFormat{}, "1_000", 0, false},
+		{NumberFormat{}, "Inf", 0, false},
+		{NumberFormat{}, "-inf", 0, false},
+		{NumberFormat{}, "NaN", 0, false},
+		{NumberFormat{}, "1.2.3", 0, false},
+		{NumberFormat{}, "abc", 0, false},
+	} {
+		got, err := test.format.Parse(test.value)
+		if test.ok && (err != nil || got != test.want) {
+			t.Errorf("%+v.Parse(%q) = %v, %v, want %v", test.format, test.value, got, err, test.want)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%+v.Parse(%q) = %v, want an error", test.format, test.value, got)
+		}
+	}
+	if _, err := (NumberFormat{}).Parse("*****"); !errors.Is(err, ErrNumberOverflow) {
+		t.Errorf("overflow marker: found %v, want ErrNumberOverflow", err)
+	}
+}
+
+func TestConfigSeparators(t *testing.T) {
+	for _, separators := range []string{
+		"decimalseparator: ','\nthousandsseparator: ','",
+		"decimalseparator: ','\ndelimiter: ','",
+		"decimalseparator: ','\ndelimiter: comma",
+		"thousandsseparator: ';'\ndelimiter: semicolon",
+	} {
+		if _, err := ReadConfigFile(writeConfig(t, separators+"\ncolumntograph: {}\n")); err == nil {
+			t.Errorf("%q: expected an error for equal separators", separators)
+		}
+	}
+	if _, err := ReadConfigFile(writeConfig(t, "decimalseparator: ','\ndelimiter: semicolon\ncolumntograph: {}\n")); err != nil {
+		t.Errorf("decimal comma with semicolon delimiter: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package cropgraph
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// results of the profile operation
+const (
+	// depth-weighted mean of the layers, e.g. the water content of the profile
+	ProfileMean = "mean"
+	// volumetric water content times thickness in mm
+	ProfileStorage = "storage"
+	// sum of the layers, partial layers count by their share in the interval, e.g. Nmin in kg N/ha
+	ProfileTotal = "total"
+)
+
+// profile parameters, which may be read from a soil profile file
+var profileFileParameters = []string{"depths", "thickness"}
+
+// floatListParameter returns the named list of numbers of the operation, given as yaml list,
+// comma separated text or a single number, false if it is not set
+func floatListParameter(operationDefinition OperationDefinition, name string) ([]float64, bool, error) {
+	value, ok := operationDefinition.Parameters[name]
+	if !ok {
+		return nil, false, nil
+	}
+	var items []interface{}
+	switch list := value.(type) {
+	case []interface{}:
+		items = list
+	case string:
+		for _, item := range strings.Split(list, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	default:
+		items = []interface{}{value}
+	}
+	numbers := make([]float64, len(items))
+	for i, item := range items {
+		number, err := floatParameter(OperationDefinition{Name: operationDefinition.Name, Parameters: map[string]interface{}{name: item}}, name, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		numbers[i] = number
+	}
+	return numbers, true, nil
+}
+
+// layerDepths returns the lower boundaries of the layers in cm, given by the parameter depths
+// or by the parameter thickness, a single thickness of all layers or the thickness of each layer
+func layerDepths(operationDefinition OperationDefinition, numLayers int) ([]float64, error) {
+	depths, ok, err := floatListParameter(operationDefinition, "depths")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		thickness, ok, err := floatListParameter(operationDefinition, "thickness")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("operation %s requires the parameter depths or thickness", operationDefinition.Name)
+		}
+		if len(thickness) == 1 && numLayers > 1 {
+			thickness = repeatFloat(thickness[0], numLayers)
+		}
+		depths = make([]float64, len(thickness))
+		bottom := 0.0
+		for i, layer := range thickness {
+			bottom += layer
+			depths[i] = bottom
+		}
+	}
+	if len(depths) == 0 {
+		return nil, fmt.Errorf("operation %s: no layer depths", operationDefinition.Name)
+	}
+	for i, depth := range depths {
+		if (i == 0 && depth <= 0) || (i > 0 && depth <= depths[i-1]) {
+			return nil, fmt.Errorf("operation %s: layer depths must increase from 0, found %v", operationDefinition.Name, depths)
+		}
+	}
+	if numLayers > 0 && len(depths) != numLayers {
+		return nil, fmt.Errorf("operation %s: %d layer depths for %d columns", operationDefinition.Name, len(depths), numLayers)
+	}
+	return depths, nil
+}
+
+// repeatFloat returns a list of n times the value
+func repeatFloat(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// profileInterval returns the depth interval of the profile operation in cm, default the whole profile
+func profileInterval(operationDefinition OperationDefinition, depths []float64) (float64, float64, error) {
+	top, err := floatParameter(operationDefinition, "top", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	bottom, err := floatParameter(operationDefinition, "bottom", depths[len(depths)-1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if top < 0 || bottom <= top || bottom > depths[len(depths)-1] {
+		return 0, 0, fmt.Errorf("operation %s: interval %g-%g cm is not within the profile 0-%g cm",
+			operationDefinition.Name, top, bottom, depths[len(depths)-1])
+	}
+	return top, bottom, nil
+}
+
+// validateProfile checks the parameters of the profile operation, which do not depend on the columns
+func validateProfile(operationDefinition OperationDefinition) error {
+	switch result := stringParameter(operationDefinition, "result", ProfileMean); result {
+	case ProfileMean, ProfileStorage, ProfileTotal:
+	default:
+		return fmt.Errorf("operation %s: unknown result %s, use mean, storage or total", operationDefinition.Name, result)
+	}
+	depths, err := layerDepths(operationDefinition, 0)
+	if err != nil {
+		return err
+	}
+	// a single thickness of all layers gives the depth of the profile with the columns
+	if thickness, _, _ := floatListParameter(operationDefinition, "thickness"); len(thickness) == 1 && len(depths) == 1 {
+		depths[0] = math.Inf(1)
+	}
+	_, _, err = profileInterval(operationDefinition, depths)
+	return err
+}
+
+// profileOperation aggregates the layers of a soil profile, given as columns from top to bottom,
+// over a depth interval, with the parameters
+// depths or thickness: lower boundaries or thicknesses of the layers in cm
+// top, bottom: depth interval in cm, default the whole profile, partial layers count by their share
+// result: mean (depth-weighted mean, default), storage (volumetric content times thickness in mm)
+// or total (sum of the layers)
+func profileOperation(operationDefinition OperationDefinition, columnValues []*Column, skipMissing bool) (*Column, error) {
+	depths, err := layerDepths(operationDefinition, len(columnValues))
+	if err != nil {
+		return nil, err
+	}
+	top, bottom, err := profileInterval(operationDefinition, depths)
+	if err != nil {
+		return nil, err
+	}
+	result := stringParameter(operationDefinition, "result", ProfileMean)
+
+	// thickness of each layer within the interval in cm
+	weights := make([]float64, len(depths))
+	for i, depth := range depths {
+		weights[i] = max(0, min(depth, bottom)-max(depthAbove(depths, i), top))
+	}
+	// factor of each layer to the result
+	factors := make([]float64, len(depths))
+	for i, weight := range weights {
+		switch result {
+		case ProfileMean:
+			factors[i] = weight
+		case ProfileTotal:
+			factors[i] = weight / (depths[i] - depthAbove(depths, i))
+		case ProfileStorage:
+			// a volume fraction times cm gives 10 mm
+			toFraction, err := volumeFraction(operationDefinition, columnValues[i])
+			if err != nil {
+				return nil, err
+			}
+			factors[i] = weight * 10 * toFraction
+		default:
+			return nil, fmt.Errorf("operation %s: unknown result %s, use mean, storage or total", operationDefinition.Name, result)
+		}
+	}
+
+	newColumn := newResultColumn(columnValues[0].Len())
+	for row := 0; row < newColumn.Len(); row++ {
+		sum := 0.0
+		weightSum := 0.0
+		missing := false
+		for i, column := range columnValues {
+			if weights[i] == 0 {
+				continue
+			}
+			if !column.Valid[row] {
+				missing = true
+				continue
+			}
+			sum += factors[i] * column.Floats[row]
+			weightSum += weights[i]
+		}
+		if weightSum == 0 || (missing && !skipMissing) {
+			continue
+		}
+		if result == ProfileMean {
+			sum /= weightSum
+		}
+		newColumn.Floats[row] = sum
+		newColumn.Valid[row] = true
+	}
+	switch result {
+	case ProfileStorage:
+		newColumn.Unit = "mm"
+	default:
+		newColumn.Unit = columnValues[0].Unit
+	}
+	return newColumn, nil
+}
+
+// depthAbove returns the upper boundary of the layer
+func depthAbove(depths []float64, layer int) float64 {
+	if layer == 0 {
+		return 0
+	}
+	return depths[layer-1]
+}
+
+// readProfileFile reads the layer depths or thicknesses of a soil profile file, a yaml file with the parameters
+// depths or thickness, a relative path is relative to the directory of the config file
+func readProfileFile(profileFile, configFile string) (map[string]interface{}, error) {
+	profile := map[string]interface{}{}
+	if err := readConfigRelativeYAML(profileFile, configFile, &profile); err != nil {
+		return nil, err
+	}
+	parameters := map[string]interface{}{}
+	for _, name := range profileFileParameters {
+		if value, ok := profile[name]; ok {
+			parameters[name] = value
+		}
+	}
+	if len(parameters) == 0 {
+		return nil, fmt.Errorf("%s: soil profile requires depths or thickness", profileFile)
+	}
+	return parameters, nil
+}
+
+// withProfileFile returns the operation with the parameters of its soil profile file,
+// parameters of the operation take precedence
+func withProfileFile(operationDefinition OperationDefinition, configFile string) (OperationDefinition, error) {
+	profileFile := stringParameter(operationDefinition, "profilefile", "")
+	if profileFile == "" {
+		return operationDefinition, nil
+	}
+	parameters, err := readProfileFile(profileFile, configFile)
+	if err != nil {
+		return operationDefinition, fmt.Errorf("operation %s: %w", operationDefinition.Name, err)
+	}
+	// depths or thickness of the operation replace the profile file
+	if _, ok := operationDefinition.Parameters["depths"]; ok {
+		return operationDefinition, nil
+	}
+	if _, ok := operationDefinition.Parameters["thickness"]; ok {
+		return operationDefinition, nil
+	}
+	for name, value := range parameters {
+		operationDefinition = withDefaultParameter(operationDefinition, name, value)
+	}
+	return operationDefinition, nil
+}
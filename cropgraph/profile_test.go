@@ -0,0 +1,102 @@
+package cropgraph
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	missing := math.NaN()
+	// layers 0-10, 10-30 and 30-60 cm, the second row misses the middle layer and the third the lowest layer
+	water := []*Column{
+		missingColumn("SWC 1", []float64{20, 20, 20}),
+		missingColumn("SWC 2", []float64{30, missing, 30}),
+		missingColumn("SWC 3", []float64{40, 40, missing}),
+	}
+	for _, column := range water {
+		column.Unit = "vol%"
+	}
+	nitrogen := []*Column{
+		NewFloatColumn("Nmin 1", []float64{10}),
+		NewFloatColumn("Nmin 2", []float64{20}),
+		NewFloatColumn("Nmin 3", []float64{30}),
+	}
+	depths := []interface{}{10, 30, 60}
+	for _, test := range []struct {
+		name       string
+		parameters map[string]interface{}
+		columns    []*Column
+		want       []float64
+	}{
+		{"storage", map[string]interface{}{"depths": depths, "result": ProfileStorage}, water, []float64{200, missing, missing}},
+		// partial layers count by their share of the interval
+		{"storage interval", map[string]interface{}{"depths": depths, "result": ProfileStorage, "top": 5, "bottom": 45}, water, []float64{130, missing, missing}},
+		// layers outside the interval do not need a value
+		{"storage above", map[string]interface{}{"depths": depths, "result": ProfileStorage, "bottom": 30}, water, []float64{80, missing, 80}},
+		{"storage thickness", map[string]interface{}{"thickness": 10, "result": ProfileStorage}, water, []float64{90, missing, missing}},
+		{"mean", map[string]interface{}{"depths": depths, "top": 5, "bottom": 45}, water, []float64{32.5, missing, missing}},
+		{"mean skip", map[string]interface{}{"depths": depths, "top": 5, "bottom": 45, "missing": "skip"}, water, []float64{32.5, 35, 28}},
+		{"total", map[string]interface{}{"depths": depths, "result": ProfileTotal, "top": 5, "bottom": 45}, nitrogen, []float64{40}},
+	} {
+		operation := OperationDefinition{Operation: "profile", Name: "profile", Parameters: test.parameters}
+		result, err := HandleColumnViewOperation(operation, test.columns, OperationContext{})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if values := resultValues(result); !equalValues(values, test.want) {
+			t.Errorf("%s: profile = %v, want %v", test.name, values, test.want)
+		}
+	}
+}
+
+func TestProfileUnit(t *testing.T) {
+	fraction := []*Column{NewFloatColumn("SWC 1", []float64{0.2}), NewFloatColumn("SWC 2", []float64{0.3})}
+	for result, want := range map[string]string{ProfileStorage: "mm", ProfileMean: ""} {
+		operation := OperationDefinition{Operation: "profile", Name: "swc", Parameters: map[string]interface{}{"depths": []interface{}{10, 30}, "result": result}}
+		column, err := HandleColumnViewOperation(operation, fraction, OperationContext{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if column.Unit != want {
+			t.Errorf("%s: unit = %q, want %q", result, column.Unit, want)
+		}
+	}
+}
+
+func TestProfileEmptyLayers(t *testing.T) {
+	for _, operation := range []OperationDefinition{
+		{Operation: "profile", Name: "swc", Parameters: map[string]interface{}{"depths": []interface{}{}}},
+		{Operation: "profile", Name: "swc", Parameters: map[string]interface{}{"thickness": []interface{}{}}},
+		{Operation: "paw", Name: "paw", Parameters: map[string]interface{}{"result": "mm", "depths": []interface{}{}}},
+	} {
+		err := validateOperation(operation, nil)
+		if err == nil || !strings.Contains(err.Error(), "no layer depths") {
+			t.Errorf("%s %v: expected an error for the empty layers, found %v", operation.Operation, operation.Parameters, err)
+		}
+	}
+}
+
+func TestProfileFewerLayersThanColumns(t *testing.T) {
+	operation := OperationDefinition{Operation: "profile", Name: "swc", Parameters: map[string]interface{}{"depths": []interface{}{30}}}
+	columns := []*Column{NewFloatColumn("SWC 1", []float64{20}), NewFloatColumn("SWC 2", []float64{25})}
+	if _, err := HandleColumnViewOperation(operation, columns, OperationContext{}); err == nil {
+		t.Error("expected an error for one layer depth and two columns")
+	}
+}
+
+func TestVolumeFractionError(t *testing.T) {
+	nitrogen := NewFloatColumn("Nmin 1", []float64{20})
+	nitrogen.Unit = "kg/ha"
+	profile := OperationDefinition{Operation: "profile", Name: "n", Parameters: map[string]interface{}{"depths": 30, "result": ProfileStorage}}
+	_, profileErr := HandleColumnViewOperation(profile, []*Column{nitrogen}, OperationContext{})
+	fieldCapacity := NewFloatColumn("FC 1", []float64{30})
+	wiltingPoint := NewFloatColumn("WP 1", []float64{10})
+	paw := OperationDefinition{Operation: "paw", Name: "n"}
+	_, pawErr := HandleColumnViewOperation(paw, []*Column{nitrogen},
+		OperationContext{Columns: map[string]*Column{"FC 1": fieldCapacity, "WP 1": wiltingPoint}})
+	if profileErr == nil || pawErr == nil || profileErr.Error() != pawErr.Error() {
+		t.Errorf("expected the same error of profile and paw, found %v and %v", profileErr, pawErr)
+	}
+}
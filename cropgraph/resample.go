@@ -0,0 +1,228 @@
+package cropgraph
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResampleDefinition aggregates the rows of a graph to weeks, months, years or periods of days
+type ResampleDefinition struct {
+	// length of the periods: week, month, year or a number of days (e.g. 10d)
+	Period string
+	// aggregator of the columns: sum, mean, min, max or last, default mean
+	Aggregate string `yaml:",omitempty"`
+	// aggregators of single columns by name or pattern (e.g. Precip: sum), override the catalog
+	Columns map[string]string `yaml:",omitempty"`
+}
+
+// aggregators reduce the valid values of a period to one value
+var aggregators = map[string]func(values []float64) float64{
+	"sum": func(values []float64) float64 {
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum
+	},
+	"mean": func(values []float64) float64 {
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
+	},
+	"min": func(values []float64) float64 {
+		return slices.Min(values)
+	},
+	"max": func(values []float64) float64 {
+		return slices.Max(values)
+	},
+	"last": func(values []float64) float64 {
+		return values[len(values)-1]
+	},
+}
+
+// resamplePeriod is a calendar period (week, month, year) or a number of days
+type resamplePeriod struct {
+	calendar string
+	days     int
+}
+
+// parseResamplePeriod parses week, month, year or a number of days with optional suffix d
+func parseResamplePeriod(text string) (resamplePeriod, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	switch text {
+	case "week", "month", "year":
+		return resamplePeriod{calendar: text}, nil
+	case "day":
+		return resamplePeriod{days: 1}, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+	if err != nil || days < 1 {
+		return resamplePeriod{}, fmt.Errorf("invalid resample period %q (week, month, year or number of days)", text)
+	}
+	return resamplePeriod{days: days}, nil
+}
+
+// start returns the first day of the period of the date, weeks start on monday,
+// periods of days are counted from the day of origin
+func (period resamplePeriod) start(date, origin time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	switch period.calendar {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	case "year":
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, day.Location())
+	}
+	// count calendar days, independent of daylight saving time
+	utcDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	utcOrigin := time.Date(origin.Year(), origin.Month(), origin.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(utcDay.Sub(utcOrigin).Hours() / 24)
+	offset := days - ((days%period.days)+period.days)%period.days
+	return time.Date(origin.Year(), origin.Month(), origin.Day()+offset, 0, 0, 0, 0, day.Location())
+}
+
+// validate checks the period and the aggregators
+func (definition ResampleDefinition) validate() error {
+	if _, err := parseResamplePeriod(definition.Period); err != nil {
+		return err
+	}
+	if _, ok := aggregators[definition.Aggregate]; !ok && definition.Aggregate != "" {
+		return fmt.Errorf("unknown aggregator %q", definition.Aggregate)
+	}
+	for column, aggregate := range definition.Columns {
+		if _, ok := aggregators[aggregate]; !ok {
+			return fmt.Errorf("column %s: unknown aggregator %q", column, aggregate)
+		}
+		if isColumnPattern(column) {
+			if _, err := parseColumnPattern(column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// aggregator returns the aggregator of the column, given by name or pattern of the resample definition,
+// by the catalog or by the default aggregator of the definition
+func (definition ResampleDefinition) aggregator(name string, catalog ColumnCatalog) string {
+	if aggregate, ok := definition.Columns[name]; ok {
+		return aggregate
+	}
+	keys := make([]string, 0, len(definition.Columns))
+	for key := range definition.Columns {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !isColumnPattern(key) {
+			continue
+		}
+		if pattern, err := parseColumnPattern(key); err == nil && pattern.Match(name) {
+			return definition.Columns[key]
+		}
+	}
+	if entry, ok := catalog.Lookup(name); ok && entry.Aggregate != "" {
+		return entry.Aggregate
+	}
+	if definition.Aggregate != "" {
+		return definition.Aggregate
+	}
+	return "mean"
+}
+
+// resample aggregates the columns to the periods of the date column,
+// returns the first day of each period as new date column and the aggregated columns
+// rows without valid date are left out, missing values are skipped, a period without values is missing
+func resample(definition ResampleDefinition, dateColumn *Column, columns []*Column, names []string, catalog ColumnCatalog) (*Column, []*Column, error) {
+	if !hasTimeAxis(dateColumn) {
+		return nil, nil, fmt.Errorf("resample requires a date column")
+	}
+	period, err := parseResamplePeriod(definition.Period)
+	if err != nil {
+		return nil, nil, err
+	}
+	// group the rows by the start of their period
+	type periodRows struct {
+		start time.Time
+		rows  []int
+	}
+	groups := []*periodRows{}
+	byStart := map[int64]*periodRows{}
+	var origin time.Time
+	for row := 0; row < dateColumn.Len(); row++ {
+		if !dateColumn.Valid[row] {
+			continue
+		}
+		if len(groups) == 0 {
+			origin = dateColumn.Times[row]
+		}
+		start := period.start(dateColumn.Times[row], origin)
+		group, ok := byStart[start.Unix()]
+		if !ok {
+			group = &periodRows{start: start}
+			byStart[start.Unix()] = group
+			groups = append(groups, group)
+		}
+		group.rows = append(group.rows, row)
+	}
+	slices.SortStableFunc(groups, func(a, b *periodRows) int {
+		return a.start.Compare(b.start)
+	})
+
+	newDates := &Column{Name: dateColumn.Name, Type: TimeColumn, Unit: dateColumn.Unit,
+		Times: make([]time.Time, len(groups)), Valid: make([]bool, len(groups))}
+	for i, group := range groups {
+		newDates.Times[i] = group.start
+		newDates.Valid[i] = true
+	}
+	newColumns := make([]*Column, len(columns))
+	for i, column := range columns {
+		aggregate := aggregators[definition.aggregator(names[i], catalog)]
+		newColumn := &Column{Name: column.Name, Type: column.Type, Unit: column.Unit, Valid: make([]bool, len(groups))}
+		switch column.Type {
+		case FloatColumn:
+			newColumn.Floats = make([]float64, len(groups))
+		case TimeColumn:
+			newColumn.Times = make([]time.Time, len(groups))
+		default:
+			newColumn.Strings = make([]string, len(groups))
+		}
+		for j, group := range groups {
+			values := make([]float64, 0, len(group.rows))
+			last := -1
+			for _, row := range group.rows {
+				if row >= column.Len() || !column.Valid[row] {
+					continue
+				}
+				if column.Type == FloatColumn {
+					values = append(values, column.Floats[row])
+				}
+				last = row
+			}
+			if last < 0 {
+				continue
+			}
+			// dates and texts keep the last value of the period
+			switch column.Type {
+			case FloatColumn:
+				newColumn.Floats[j] = aggregate(values)
+				newColumn.Valid[j] = !math.IsNaN(newColumn.Floats[j])
+			case TimeColumn:
+				newColumn.Times[j] = column.Times[last]
+				newColumn.Valid[j] = true
+			default:
+				newColumn.Strings[j] = column.Strings[last]
+				newColumn.Valid[j] = true
+			}
+		}
+		newColumns[i] = newColumn
+	}
+	return newDates, newColumns, nil
+}
@@ -0,0 +1,64 @@
+package cropgraph
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// dailyColumns returns a date column of consecutive days from the start and a column of the values
+func dailyColumns(start time.Time, values []float64) (*Column, *Column) {
+	dates := &Column{Name: "Date", Type: TimeColumn}
+	for i := range values {
+		dates.Times = append(dates.Times, start.AddDate(0, 0, i))
+		dates.Valid = append(dates.Valid, true)
+	}
+	return dates, NewFloatColumn("Precip", values)
+}
+
+func TestResample(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	// thursday 2022-12-29 to monday 2023-01-09
+	dates, precip := dailyColumns(day(2022, 12, 29), []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	for _, test := range []struct {
+		period    string
+		aggregate string
+		dates     []time.Time
+		values    []float64
+	}{
+		{"week", "sum", []time.Time{day(2022, 12, 26), day(2023, 1, 2), day(2023, 1, 9)}, []float64{10, 56, 12}},
+		{"week", "mean", []time.Time{day(2022, 12, 26), day(2023, 1, 2), day(2023, 1, 9)}, []float64{2.5, 8, 12}},
+		{"month", "sum", []time.Time{day(2022, 12, 1), day(2023, 1, 1)}, []float64{6, 72}},
+		{"month", "", []time.Time{day(2022, 12, 1), day(2023, 1, 1)}, []float64{2, 8}},
+		{"year", "sum", []time.Time{day(2022, 1, 1), day(2023, 1, 1)}, []float64{6, 72}},
+		{"year", "max", []time.Time{day(2022, 1, 1), day(2023, 1, 1)}, []float64{3, 12}},
+		{"5d", "sum", []time.Time{day(2022, 12, 29), day(2023, 1, 3), day(2023, 1, 8)}, []float64{15, 40, 23}},
+	} {
+		definition := ResampleDefinition{Period: test.period, Aggregate: test.aggregate}
+		newDates, newColumns, err := resample(definition, dates, []*Column{precip}, []string{"Precip"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.EqualFunc(newDates.Times, test.dates, time.Time.Equal) || !slices.Equal(newColumns[0].Floats, test.values) {
+			t.Errorf("%s %s: found %v %v, want %v %v", test.period, test.aggregate, newDates.Times, newColumns[0].Floats, test.dates, test.values)
+		}
+	}
+}
+
+func TestResampleMissingValues(t *testing.T) {
+	dates, precip := dailyColumns(time.Date(2023, 1, 30, 0, 0, 0, 0, time.UTC), []float64{2, 4, 6, 8, 10})
+	// the values of january are missing, a value of february is skipped
+	precip.Valid[0] = false
+	precip.Valid[1] = false
+	precip.Valid[3] = false
+	_, newColumns, err := resample(ResampleDefinition{Period: "month", Columns: map[string]string{"Prec*": "sum"}},
+		dates, []*Column{precip}, []string{"Precip"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if column := newColumns[0]; !slices.Equal(column.Valid, []bool{false, true}) || column.Floats[1] != 16 {
+		t.Errorf("found %v %v", column.Floats, column.Valid)
+	}
+}
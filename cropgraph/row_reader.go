@@ -0,0 +1,88 @@
+package cropgraph
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// named delimiters of Config.Delimiter
+var namedDelimiters = map[string]string{
+	"comma":     ",",
+	"semicolon": ";",
+	"tab":       "\t",
+	"pipe":      "|",
+	// runs of spaces and tabs
+	"space":      "whitespace",
+	"whitespace": "whitespace",
+}
+
+// rowReader reads the rows of a delimited text file
+type rowReader interface {
+	// Read reads the next row, io.EOF at the end of the file
+	Read() ([]string, error)
+	// Line returns the line number of the last row read
+	Line() int
+}
+
+// newRowReader creates a reader for the delimiter of the config,
+// a single character, or a named delimiter (comma, semicolon, tab, pipe, space, whitespace)
+func newRowReader(input io.Reader, delimiter string) (rowReader, error) {
+	if named, ok := namedDelimiters[strings.ToLower(delimiter)]; ok {
+		delimiter = named
+	}
+	if delimiter == "whitespace" {
+		return &whitespaceRowReader{scanner: bufio.NewScanner(input)}, nil
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return nil, fmt.Errorf("invalid delimiter %q, use a single character or one of comma, semicolon, tab, pipe, space, whitespace", delimiter)
+	}
+	// go csv reader
+	// https://golang.org/pkg/encoding/csv/
+	reader := csv.NewReader(input)
+	reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	// the number of fields is checked by the caller
+	reader.FieldsPerRecord = -1
+	return &csvRowReader{reader: reader}, nil
+}
+
+// csvRowReader reads rows separated by a single character
+type csvRowReader struct {
+	reader *csv.Reader
+}
+
+func (rows *csvRowReader) Read() ([]string, error) {
+	return rows.reader.Read()
+}
+
+func (rows *csvRowReader) Line() int {
+	line, _ := rows.reader.FieldPos(0)
+	return line
+}
+
+// whitespaceRowReader reads rows separated by runs of spaces and tabs, empty lines are skipped
+type whitespaceRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (rows *whitespaceRowReader) Read() ([]string, error) {
+	for rows.scanner.Scan() {
+		rows.line++
+		fields := strings.Fields(rows.scanner.Text())
+		if len(fields) > 0 {
+			return fields, nil
+		}
+	}
+	if err := rows.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (rows *whitespaceRowReader) Line() int {
+	return rows.line
+}
@@ -0,0 +1,260 @@
+package cropgraph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// number of lines inspected by the sniffer
+const sniffLines = 50
+
+// delimiters tried by the sniffer, whitespace is tried last
+var sniffDelimiters = []string{",", ";", "\t", "|"}
+
+// date formats tried by the sniffer, day first formats are preferred over month first formats
+var sniffDateFormats = []string{
+	"02.01.2006",
+	"2006-01-02",
+	"02/01/2006",
+	"01/02/2006",
+	"2006/01/02",
+	"02-01-2006",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+}
+
+// SniffResult holds the input format inferred from the first rows of an input file
+type SniffResult struct {
+	// delimiter of the fields, a single character or "whitespace"
+	Delimiter string
+	// number of header lines (column names and e.g. units) before the first data row
+	NumHeader int
+	// date format of the first date column, empty if no date column is found
+	DateFormat string
+	// name of the first date column
+	DateColumn string
+}
+
+func (result SniffResult) String() string {
+	delimiter := result.Delimiter
+	if delimiter == "\t" {
+		delimiter = "tab"
+	}
+	description := fmt.Sprintf("delimiter %q, %d header lines", delimiter, result.NumHeader)
+	if result.DateFormat != "" {
+		description += fmt.Sprintf(", date format %q (column %s)", result.DateFormat, result.DateColumn)
+	}
+	return description
+}
+
+// SniffInputFormat infers delimiter, number of header lines and date format from the first rows of the input file,
+// the delimiter and number of header lines of the config are used if given,
+// numbers are read in the number format of the config and its missing value tokens are skipped
+func SniffInputFormat(inputFile string, config Config) (*SniffResult, error) {
+	lines, err := readSniffLines(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s: empty input file", inputFile)
+	}
+
+	numberFormat := config.NumberFormat()
+	result := &SniffResult{Delimiter: config.Delimiter}
+	if named, ok := namedDelimiters[strings.ToLower(config.Delimiter)]; ok {
+		result.Delimiter = named
+	}
+	if result.Delimiter == "" {
+		result.Delimiter = sniffDelimiter(lines, numberFormat)
+	}
+	rows := make([][]string, len(lines))
+	for i, line := range lines {
+		rows[i] = splitLine(line, result.Delimiter)
+	}
+
+	// the header ends with the first row of numbers and dates
+	result.NumHeader = -1
+	if config.NumHeader > 0 {
+		result.NumHeader = min(config.NumHeader, len(rows))
+	} else {
+		for i, row := range rows {
+			if isDataRow(row, numberFormat, config.NATokens) {
+				result.NumHeader = i
+				break
+			}
+		}
+	}
+	if result.NumHeader < 0 || result.NumHeader == len(rows) {
+		return nil, fmt.Errorf("%s: no data row in the first %d lines, check the delimiter and the number format", inputFile, len(rows))
+	}
+	dataRows := rows[result.NumHeader:]
+	for colIndex := range dataRows[0] {
+		if dateFormat := sniffDateFormat(dataRows, colIndex, config.NATokens); dateFormat != "" {
+			result.DateFormat = dateFormat
+			if result.NumHeader > 0 && colIndex < len(rows[0]) {
+				result.DateColumn = strings.TrimSpace(rows[0][colIndex])
+			}
+			break
+		}
+	}
+	return result, nil
+}
+
+// readSniffLines returns the first non-empty lines of the file inspected by the sniffer
+func readSniffLines(inputFile string) ([]string, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for len(lines) < sniffLines && scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// sniffDelimiter returns the delimiter that splits the most lines into the same number of fields,
+// the separators of the number format are no delimiters
+func sniffDelimiter(lines []string, numberFormat NumberFormat) string {
+	bestDelimiter := ""
+	bestScore := 0
+	for _, delimiter := range append(sniffDelimiters, "whitespace") {
+		if delimiter == numberFormat.DecimalSeparator || delimiter == numberFormat.ThousandsSeparator {
+			continue
+		}
+		counts := map[int]int{}
+		for _, line := range lines {
+			counts[len(splitLine(line, delimiter))]++
+		}
+		for numFields, numLines := range counts {
+			if numFields < 2 {
+				continue
+			}
+			// consistent lines first, more fields on a tie
+			score := numLines*1000 + numFields
+			if score > bestScore {
+				bestScore = score
+				bestDelimiter = delimiter
+			}
+		}
+	}
+	if bestDelimiter == "" {
+		// a single column
+		return sniffDelimiters[0]
+	}
+	return bestDelimiter
+}
+
+// splitLine splits a line at the delimiter, quotes are not considered
+func splitLine(line, delimiter string) []string {
+	if delimiter == "whitespace" {
+		return strings.Fields(line)
+	}
+	return strings.Split(line, delimiter)
+}
+
+// isDataRow checks if most non-empty fields of the row are numbers of the number format or dates,
+// missing value tokens are not counted
+func isDataRow(row []string, numberFormat NumberFormat, naTokens []string) bool {
+	numFields := 0
+	numValues := 0
+	for _, field := range row {
+		field = strings.Trim(strings.TrimSpace(field), `"`)
+		if field == "" || slices.Contains(naTokens, field) {
+			continue
+		}
+		numFields++
+		_, err := numberFormat.Parse(field)
+		if err == nil || errors.Is(err, ErrNumberOverflow) || matchDateFormat(field) != "" {
+			numValues++
+		}
+	}
+	return numFields > 0 && numValues*2 > numFields
+}
+
+// matchDateFormat returns the first sniffer date format that parses the value
+func matchDateFormat(value string) string {
+	for _, dateFormat := range sniffDateFormats {
+		if _, err := time.Parse(dateFormat, value); err == nil {
+			return dateFormat
+		}
+	}
+	return ""
+}
+
+// sniffDateFormat returns the first date format that parses the column in all data rows,
+// empty and missing values are left out
+func sniffDateFormat(dataRows [][]string, colIndex int, naTokens []string) string {
+	for _, dateFormat := range sniffDateFormats {
+		matches := false
+		for _, row := range dataRows {
+			if colIndex >= len(row) {
+				continue
+			}
+			value := strings.Trim(strings.TrimSpace(row[colIndex]), `"`)
+			if value == "" || slices.Contains(naTokens, value) {
+				continue
+			}
+			if _, err := time.Parse(dateFormat, value); err != nil {
+				matches = false
+				break
+			}
+			matches = true
+		}
+		if matches {
+			return dateFormat
+		}
+	}
+	return ""
+}
+
+// sniffMissingFormat fills delimiter, number of header lines and date format of the config,
+// if they are not given, from the first rows of the input file and reports the inferred values,
+// the same inferred values are reported once per config
+func sniffMissingFormat(inputFile string, config Config) (Config, error) {
+	if config.Delimiter != "" && config.NumHeader > 0 && config.DateFormat != "" {
+		return config, nil
+	}
+	result, err := SniffInputFormat(inputFile, config)
+	if err != nil {
+		return config, err
+	}
+	inferred := []string{}
+	if config.Delimiter == "" {
+		config.Delimiter = result.Delimiter
+		delimiter := result.Delimiter
+		if delimiter == "\t" {
+			delimiter = "tab"
+		}
+		inferred = append(inferred, fmt.Sprintf("delimiter %q", delimiter))
+	}
+	if config.NumHeader <= 0 {
+		config.NumHeader = result.NumHeader
+		inferred = append(inferred, fmt.Sprintf("%d header lines", result.NumHeader))
+	}
+	if config.DateFormat == "" && result.DateFormat != "" {
+		config.DateFormat = result.DateFormat
+		inferred = append(inferred, fmt.Sprintf("date format %q (column %s)", result.DateFormat, result.DateColumn))
+	}
+	report := strings.Join(inferred, ", ")
+	if len(inferred) > 0 && !config.inferredFormats[report] {
+		fmt.Println("inferred", report, "from", inputFile)
+		if config.inferredFormats != nil {
+			config.inferredFormats[report] = true
+		}
+	}
+	return config, nil
+}
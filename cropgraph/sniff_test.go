@@ -0,0 +1,114 @@
+package cropgraph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeInput writes the content of an input file to a temporary directory and returns its path
+func writeInput(t *testing.T, name, content string) string {
+	t.Helper()
+	inputFile := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(inputFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return inputFile
+}
+
+func TestSniffInputFormat(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		config  Config
+		content string
+		want    SniffResult
+	}{
+		{"comma with units", Config{},
+			"Date,LAI,Yield\ndd.mm.yyyy,m2/m2,kg/ha\n08.07.2022,0.5,100\n09.07.2022,0.6,110\n",
+			SniffResult{Delimiter: ",", NumHeader: 2, DateFormat: "02.01.2006", DateColumn: "Date"}},
+		{"semicolon with decimal comma", Config{DecimalSeparator: ","},
+			"Date;LAI;Yield\n2022-07-08;0,5;100,5\n2022-07-09;0,6;110,25\n",
+			SniffResult{Delimiter: ";", NumHeader: 1, DateFormat: "2006-01-02", DateColumn: "Date"}},
+		{"tab", Config{},
+			"Date\tLAI\n2022-07-08\t0.5\n2022-07-09\t0.6\n",
+			SniffResult{Delimiter: "\t", NumHeader: 1, DateFormat: "2006-01-02", DateColumn: "Date"}},
+		{"runs of spaces", Config{},
+			"Date        LAI    Yield\n2022-07-08    0.5      100\n2022-07-09   0.6     110\n",
+			SniffResult{Delimiter: "whitespace", NumHeader: 1, DateFormat: "2006-01-02", DateColumn: "Date"}},
+		{"named tab delimiter", Config{Delimiter: "tab"},
+			"Date\tLAI\n08.07.2022\t0.5\n",
+			SniffResult{Delimiter: "\t", NumHeader: 1, DateFormat: "02.01.2006", DateColumn: "Date"}},
+		{"named whitespace delimiter", Config{Delimiter: "whitespace"},
+			"Date LAI\n08.07.2022   0.5\n",
+			SniffResult{Delimiter: "whitespace", NumHeader: 1, DateFormat: "02.01.2006", DateColumn: "Date"}},
+		{"pipe with missing values", Config{NATokens: []string{"NA"}},
+			"Date|LAI|Yield\n07/31/2022|NA|NA\n08/01/2022|0.6|NA\n",
+			SniffResult{Delimiter: "|", NumHeader: 1, DateFormat: "01/02/2006", DateColumn: "Date"}},
+		{"given header lines", Config{NumHeader: 2},
+			"Date,LAI\n08.07.2022,0.5\n09.07.2022,0.6\n",
+			SniffResult{Delimiter: ",", NumHeader: 2, DateFormat: "02.01.2006", DateColumn: "Date"}},
+		{"no date column", Config{},
+			"Row,LAI\n1,0.5\n2,0.6\n",
+			SniffResult{Delimiter: ",", NumHeader: 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := SniffInputFormat(writeInput(t, "input.csv", test.content), test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *result != test.want {
+				t.Errorf("found %+v, want %+v", *result, test.want)
+			}
+		})
+	}
+}
+
+func TestSniffWithoutDataRow(t *testing.T) {
+	for _, content := range []string{
+		"Date;LAI\nday;index\n",
+		// decimal commas are no numbers of the default number format
+		"Date;LAI;Yield\n08.07.2022;0,5;1,5\n",
+	} {
+		if _, err := SniffInputFormat(writeInput(t, "input.csv", content), Config{Delimiter: ";"}); err == nil {
+			t.Errorf("%q: expected an error for a file without data rows", content)
+		}
+	}
+}
+
+func TestReadDecimalCommaInput(t *testing.T) {
+	config, err := ReadConfigFile(writeConfig(t, `
+delimiter: semicolon
+decimalseparator: ","
+columntograph:
+  g: {graphtype: line, title: LAI, columns: [Date, LAI], datecolumn: Date}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _, err := ReadFileData(writeInput(t, "input.csv", "Date;LAI\n08.07.2022;0,5\n09.07.2022;1,25\n"), *config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, _ := table.Column("Date")
+	lai, _ := table.Column("LAI")
+	if table.Len() != 2 || date.Type != TimeColumn || lai.Type != FloatColumn || lai.Floats[1] != 1.25 {
+		t.Errorf("found %d rows, date %v, lai %v", table.Len(), date, lai)
+	}
+}
+
+func TestSniffReportsInferredFormatOnce(t *testing.T) {
+	config, err := ReadConfigFile(writeConfig(t, "numheader: 2\ncolumntograph: {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "Date,LAI\ndd.mm.yyyy,m2/m2\n08.07.2022,0.5\n"
+	for _, name := range []string{"first.csv", "second.csv"} {
+		if _, err := sniffMissingFormat(writeInput(t, name, content), *config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `delimiter ",", date format "02.01.2006" (column Date)`
+	if len(config.inferredFormats) != 1 || !config.inferredFormats[want] {
+		t.Errorf("reported %v, want %s", config.inferredFormats, want)
+	}
+}
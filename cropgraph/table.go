@@ -0,0 +1,379 @@
+package cropgraph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ISO date format, used by several simulation models
+const isoDateFormat = "2006-01-02"
+
+// ColumnType is the type of the values of a column
+type ColumnType int
+
+const (
+	// StringColumn holds text values
+	StringColumn ColumnType = iota
+	// FloatColumn holds numeric values
+	FloatColumn
+	// TimeColumn holds dates
+	TimeColumn
+)
+
+// Column holds the values of a single column, parsed once at load time
+type Column struct {
+	// name of the column
+	Name string
+	// type of the values
+	Type ColumnType
+	// unit of the values, if known
+	Unit string
+	// values of a FloatColumn
+	Floats []float64
+	// values of a TimeColumn
+	Times []time.Time
+	// values of a StringColumn
+	Strings []string
+	// validity mask, false marks a missing value
+	Valid []bool
+}
+
+// NewFloatColumn creates a numeric column with all values marked as valid
+func NewFloatColumn(name string, values []float64) *Column {
+	valid := make([]bool, len(values))
+	for i := range valid {
+		valid[i] = true
+	}
+	return &Column{Name: name, Type: FloatColumn, Floats: values, Valid: valid}
+}
+
+// Len returns the number of rows of the column
+func (column *Column) Len() int {
+	return len(column.Valid)
+}
+
+// Value returns the value of the row as float64, time.Time or string, nil if the value is missing
+func (column *Column) Value(row int) interface{} {
+	if !column.Valid[row] {
+		return nil
+	}
+	switch column.Type {
+	case FloatColumn:
+		return column.Floats[row]
+	case TimeColumn:
+		return column.Times[row]
+	default:
+		return column.Strings[row]
+	}
+}
+
+// Copy returns a deep copy of the column
+func (column *Column) Copy() *Column {
+	newColumn := *column
+	newColumn.Floats = append([]float64(nil), column.Floats...)
+	newColumn.Times = append([]time.Time(nil), column.Times...)
+	newColumn.Strings = append([]string(nil), column.Strings...)
+	newColumn.Valid = append([]bool(nil), column.Valid...)
+	return &newColumn
+}
+
+// FormatTimes formats the values of a TimeColumn with the given date format
+// missing values are returned as empty strings
+func (column *Column) FormatTimes(dateFormat string) []string {
+	formatted := make([]string, column.Len())
+	for i := range formatted {
+		if column.Type == TimeColumn && column.Valid[i] {
+			formatted[i] = column.Times[i].Format(dateFormat)
+		} else if value := column.Value(i); value != nil {
+			formatted[i] = fmt.Sprint(value)
+		}
+	}
+	return formatted
+}
+
+// Table holds the typed columns read from a simulation output file
+type Table struct {
+	// column names in the order of the input file
+	names []string
+	// columns by name
+	columns map[string]*Column
+}
+
+// NewTable creates an empty table
+func NewTable() *Table {
+	return &Table{columns: map[string]*Column{}}
+}
+
+// AddColumn adds the column to the table, an existing column of the same name is replaced
+func (table *Table) AddColumn(column *Column) {
+	if _, ok := table.columns[column.Name]; !ok {
+		table.names = append(table.names, column.Name)
+	}
+	table.columns[column.Name] = column
+}
+
+// Column returns the column with the given name
+func (table *Table) Column(name string) (*Column, bool) {
+	column, ok := table.columns[name]
+	return column, ok
+}
+
+// Names returns the column names in the order of the input file
+func (table *Table) Names() []string {
+	return table.names
+}
+
+// Len returns the number of rows of the table
+func (table *Table) Len() int {
+	if len(table.names) == 0 {
+		return 0
+	}
+	return table.columns[table.names[0]].Len()
+}
+
+// tableBuilder collects the raw values of the selected columns of an input file
+// and parses them into a typed Table
+type tableBuilder struct {
+	// name of the input file
+	file string
+	// line number in the input file of each row
+	lines []int
+	// column names in the order of the input file
+	names []string
+	// raw values by column name
+	values map[string][]string
+	// units by column name
+	units map[string]string
+	// date layouts of the date columns
+	dateLayouts map[string][]string
+	// date layouts tried for columns without a date layout
+	defaultDateLayouts []string
+	// date columns built from split columns
+	splitDates []DateColumnsDefinition
+	// tokens that mark a missing value
+	naTokens map[string]bool
+	// numeric values that mark a missing value
+	naValues []float64
+	// format of the numbers
+	numberFormat NumberFormat
+	// units and valid ranges of the columns
+	catalog ColumnCatalog
+}
+
+// newTableBuilder creates a table builder for the input file with the missing value settings
+// and split date columns of the config, the default date layouts are tried for columns without a date layout
+func newTableBuilder(inputFile string, config Config, defaultDateLayouts ...string) *tableBuilder {
+	builder := &tableBuilder{
+		file:               inputFile,
+		values:             map[string][]string{},
+		units:              map[string]string{},
+		dateLayouts:        map[string][]string{},
+		defaultDateLayouts: defaultDateLayouts,
+		naTokens:           map[string]bool{},
+		numberFormat:       config.NumberFormat(),
+		catalog:            config.Catalog,
+	}
+	builder.AddNATokens(config.NATokens...)
+	builder.AddNAValues(config.NAValues...)
+	for _, definition := range config.DateColumns {
+		builder.AddSplitDate(definition)
+	}
+	return builder
+}
+
+// AddNATokens adds tokens that mark a missing value
+func (builder *tableBuilder) AddNATokens(tokens ...string) {
+	for _, token := range tokens {
+		builder.naTokens[strings.TrimSpace(token)] = true
+	}
+}
+
+// AddNAValues adds numeric values that mark a missing value
+func (builder *tableBuilder) AddNAValues(values ...float64) {
+	builder.naValues = append(builder.naValues, values...)
+}
+
+// isMissing checks if the trimmed raw value marks a missing value
+func (builder *tableBuilder) isMissing(value string) bool {
+	return value == "" || builder.naTokens[value]
+}
+
+// isNAValue checks if the parsed number is a missing value
+func (builder *tableBuilder) isNAValue(value float64) bool {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return true
+	}
+	return slices.Contains(builder.naValues, value)
+}
+
+// AddColumn adds a column to the table, adding a column twice has no effect
+func (builder *tableBuilder) AddColumn(name string) {
+	if _, ok := builder.values[name]; ok {
+		return
+	}
+	builder.names = append(builder.names, name)
+	builder.values[name] = []string{}
+}
+
+// SetUnit sets the unit of the column, surrounding brackets are removed (e.g. [kg/ha] or (kg/ha))
+// empty units and "-" (no unit) are ignored
+func (builder *tableBuilder) SetUnit(name, unit string) {
+	unit = strings.TrimSpace(unit)
+	if len(unit) > 1 && strings.ContainsRune("[(", rune(unit[0])) && strings.ContainsRune("])", rune(unit[len(unit)-1])) {
+		unit = strings.TrimSpace(unit[1 : len(unit)-1])
+	}
+	if unit == "" || unit == "-" {
+		return
+	}
+	builder.units[name] = unit
+}
+
+// SetDateFormat parses the column as dates, with the first of the given layouts that matches
+func (builder *tableBuilder) SetDateFormat(name string, layouts ...string) {
+	builder.dateLayouts[name] = layouts
+}
+
+// AddSplitDate builds a date column from split columns, which are read by the builder
+func (builder *tableBuilder) AddSplitDate(definition DateColumnsDefinition) {
+	builder.splitDates = append(builder.splitDates, definition)
+}
+
+// isSplitDate checks if the column is built from split columns
+func (builder *tableBuilder) isSplitDate(name string) bool {
+	for _, definition := range builder.splitDates {
+		if definition.columnName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StartRow starts a new row, read from the given line of the input file
+// the values of the row are added with Append
+func (builder *tableBuilder) StartRow(line int) {
+	builder.lines = append(builder.lines, line)
+}
+
+// Append appends a raw value to the column
+func (builder *tableBuilder) Append(name, value string) {
+	builder.values[name] = append(builder.values[name], value)
+}
+
+// Build parses the raw values into typed columns
+// the type of a column without date layout is decided by its first value:
+// a number makes a FloatColumn, a date in a default date layout a TimeColumn, anything else a StringColumn
+// date columns of split columns are built after the columns of the input file
+func (builder *tableBuilder) Build() (*Table, error) {
+	table := NewTable()
+	for _, name := range builder.names {
+		column, err := builder.buildColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		builder.checkCatalog(column)
+		table.AddColumn(column)
+	}
+	for _, definition := range builder.splitDates {
+		column, err := builder.buildDateColumn(table, definition)
+		if err != nil {
+			return nil, err
+		}
+		table.AddColumn(column)
+	}
+	return table, nil
+}
+
+func (builder *tableBuilder) buildColumn(name string) (*Column, error) {
+	rawValues := builder.values[name]
+	column := &Column{
+		Name:  name,
+		Type:  StringColumn,
+		Unit:  builder.units[name],
+		Valid: make([]bool, len(rawValues)),
+	}
+
+	layouts, isDate := builder.dateLayouts[name]
+	if isDate {
+		column.Type = TimeColumn
+	} else if first := builder.firstValue(rawValues); first != "" {
+		if _, err := builder.numberFormat.Parse(first); err == nil || errors.Is(err, ErrNumberOverflow) {
+			column.Type = FloatColumn
+		} else if _, err := parseTime(first, builder.defaultDateLayouts); err == nil {
+			column.Type = TimeColumn
+			layouts = builder.defaultDateLayouts
+		}
+	}
+	// the unit cell of a date column is its date format (e.g. dd.mm.yyyy), not a unit
+	if column.Type == TimeColumn {
+		column.Unit = ""
+	}
+
+	switch column.Type {
+	case FloatColumn:
+		column.Floats = make([]float64, len(rawValues))
+	case TimeColumn:
+		column.Times = make([]time.Time, len(rawValues))
+	default:
+		column.Strings = make([]string, len(rawValues))
+	}
+
+	// number of overflow markers, which are handled as missing values
+	numOverflows := 0
+	for i, rawValue := range rawValues {
+		value := strings.TrimSpace(rawValue)
+		if builder.isMissing(value) {
+			continue
+		}
+		switch column.Type {
+		case FloatColumn:
+			parsedValue, err := builder.numberFormat.Parse(value)
+			if errors.Is(err, ErrNumberOverflow) {
+				numOverflows++
+				continue
+			}
+			if err != nil {
+				return nil, builder.parseError(name, i, rawValue, err)
+			}
+			if builder.isNAValue(parsedValue) {
+				continue
+			}
+			column.Floats[i] = parsedValue
+		case TimeColumn:
+			parsedValue, err := parseTime(value, layouts)
+			if err != nil {
+				return nil, builder.parseError(name, i, rawValue, err)
+			}
+			column.Times[i] = parsedValue
+		default:
+			column.Strings[i] = rawValue
+		}
+		column.Valid[i] = true
+	}
+	if numOverflows > 0 {
+		fmt.Println("Warnung", builder.file, "column", name, "has", numOverflows, "overflow markers, handled as missing values")
+	}
+	return column, nil
+}
+
+// parseError reports a value of the column that could not be parsed
+func (builder *tableBuilder) parseError(name string, row int, value string, err error) *ParseError {
+	line := 0
+	if row < len(builder.lines) {
+		line = builder.lines[row]
+	}
+	return &ParseError{File: builder.file, Line: line, Column: name, Value: value, Err: err}
+}
+
+// firstValue returns the first value that is not missing
+func (builder *tableBuilder) firstValue(values []string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); !builder.isMissing(value) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package cropgraph
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// methods of the gdd operation to compute the degree days of a day
+const (
+	// mean temperature of the day above the base temperature
+	GDDAverage = "average"
+	// temperature rises linearly from the minimum to the maximum and falls back
+	GDDTriangle = "triangle"
+	// temperature follows a sine curve between the minimum and the maximum
+	GDDSine = "sine"
+)
+
+// gddParameters are the parameters of the gdd operation
+type gddParameters struct {
+	method     string
+	base       float64
+	cutoff     float64
+	accumulate bool
+	// accumulation starts at this date, zero time if not set
+	start time.Time
+}
+
+// parseGDDParameters reads and checks the parameters of the gdd operation
+func parseGDDParameters(operationDefinition OperationDefinition) (gddParameters, error) {
+	parameters := gddParameters{method: stringParameter(operationDefinition, "method", GDDAverage)}
+	if parameters.method != GDDAverage && parameters.method != GDDTriangle && parameters.method != GDDSine {
+		return parameters, fmt.Errorf("operation %s: unknown method %s, use average, triangle or sine", operationDefinition.Name, parameters.method)
+	}
+	var err error
+	if parameters.base, err = floatParameter(operationDefinition, "base", 0); err != nil {
+		return parameters, err
+	}
+	if parameters.cutoff, err = floatParameter(operationDefinition, "cutoff", math.Inf(1)); err != nil {
+		return parameters, err
+	}
+	if parameters.cutoff <= parameters.base {
+		return parameters, fmt.Errorf("operation %s: cutoff %g must be above the base temperature %g", operationDefinition.Name, parameters.cutoff, parameters.base)
+	}
+	if parameters.accumulate, err = boolParameter(operationDefinition, "accumulate", false); err != nil {
+		return parameters, err
+	}
+	if parameters.start, _, err = dateParameter(operationDefinition, "start"); err != nil {
+		return parameters, err
+	}
+	if _, err := parseResetDates(operationDefinition); err != nil {
+		return parameters, err
+	}
+	return parameters, nil
+}
+
+// gddOperation computes growing degree days from a mean temperature column,
+// or from a minimum and a maximum temperature column (in this order), with the parameters
+// base: base temperature, default 0
+// cutoff: upper cutoff, temperatures above count as the cutoff temperature, default none
+// method: average (default), triangle or sine, triangle and sine require minimum and maximum temperature
+// accumulate: sum up the degree days, restarted by reset and resetcolumn like cumsum
+// start: YYYY-MM-DD, the result is missing before this date
+func gddOperation(operationDefinition OperationDefinition, columnValues []*Column, context OperationContext, skipMissing bool) (*Column, error) {
+	parameters, err := parseGDDParameters(operationDefinition)
+	if err != nil {
+		return nil, err
+	}
+	if len(columnValues) > 2 {
+		return nil, fmt.Errorf("operation %s requires a mean temperature column or a minimum and a maximum temperature column", operationDefinition.Name)
+	}
+	if len(columnValues) == 1 && parameters.method != GDDAverage {
+		return nil, fmt.Errorf("operation %s: method %s requires a minimum and a maximum temperature column", operationDefinition.Name, parameters.method)
+	}
+
+	daily := newResultColumn(columnValues[0].Len())
+	for i := 0; i < daily.Len(); i++ {
+		if numValid(columnValues, i) < len(columnValues) {
+			continue
+		}
+		minimum := columnValues[0].Floats[i]
+		maximum := columnValues[len(columnValues)-1].Floats[i]
+		switch parameters.method {
+		case GDDAverage:
+			daily.Floats[i] = degreeDaysAbove((minimum+maximum)/2, parameters.base, parameters.cutoff)
+		case GDDTriangle:
+			daily.Floats[i] = triangleDegreeDays(minimum, maximum, parameters.base, parameters.cutoff)
+		case GDDSine:
+			daily.Floats[i] = sineDegreeDays(minimum, maximum, parameters.base, parameters.cutoff)
+		}
+		daily.Valid[i] = true
+	}
+
+	// rows before the start date count nothing and are hidden from the result
+	var beforeStart []bool
+	if !parameters.start.IsZero() {
+		dates, err := context.dates(operationDefinition)
+		if err != nil {
+			return nil, err
+		}
+		beforeStart = make([]bool, daily.Len())
+		for i := range beforeStart {
+			if dates.Valid[i] && dates.Times[i].Before(parameters.start) {
+				beforeStart[i] = true
+				daily.Floats[i] = 0
+				daily.Valid[i] = true
+			}
+		}
+	}
+	newColumn := daily
+	if parameters.accumulate {
+		if newColumn, err = cumsumOperation(operationDefinition, []*Column{daily}, context, skipMissing); err != nil {
+			return nil, err
+		}
+	}
+	for i, hidden := range beforeStart {
+		if hidden {
+			newColumn.Valid[i] = false
+		}
+	}
+
+	newColumn.Unit = "°C d"
+	if unit := columnValues[0].Unit; unit != "" {
+		newColumn.Unit = unit + " d"
+	}
+	return newColumn, nil
+}
+
+// degreeDaysAbove returns the temperature above the base, limited to the cutoff
+func degreeDaysAbove(temperature, base, cutoff float64) float64 {
+	return math.Max(math.Min(temperature, cutoff), base) - base
+}
+
+// triangleDegreeDays returns the degree days of a day with a linear rise from the minimum to the maximum
+// temperature and back, the temperatures of such a day are evenly distributed between minimum and maximum
+func triangleDegreeDays(minimum, maximum, base, cutoff float64) float64 {
+	if maximum <= minimum {
+		return degreeDaysAbove((minimum+maximum)/2, base, cutoff)
+	}
+	// integral of the degree days over the temperature
+	integral := func(temperature float64) float64 {
+		switch {
+		case temperature <= base:
+			return 0
+		case temperature <= cutoff:
+			return (temperature - base) * (temperature - base) / 2
+		default:
+			return (cutoff-base)*(cutoff-base)/2 + (cutoff-base)*(temperature-cutoff)
+		}
+	}
+	return (integral(maximum) - integral(minimum)) / (maximum - minimum)
+}
+
+// sineDegreeDays returns the degree days of a day with a temperature of mean + amplitude * sin(t),
+// integrated over the half period from the minimum to the maximum
+func sineDegreeDays(minimum, maximum, base, cutoff float64) float64 {
+	if maximum <= minimum {
+		return degreeDaysAbove((minimum+maximum)/2, base, cutoff)
+	}
+	mean := (maximum + minimum) / 2
+	amplitude := (maximum - minimum) / 2
+	// phases where the temperature crosses the base and the cutoff
+	phase := func(temperature float64) float64 {
+		return math.Asin(math.Max(-1, math.Min(1, (temperature-mean)/amplitude)))
+	}
+	baseCrossing := phase(base)
+	cutoffCrossing := phase(cutoff)
+	degreeDays := (mean-base)*(cutoffCrossing-baseCrossing) - amplitude*(math.Cos(cutoffCrossing)-math.Cos(baseCrossing))
+	if cutoffCrossing < math.Pi/2 {
+		// above the cutoff the degree days are constant
+		degreeDays += (cutoff - base) * (math.Pi/2 - cutoffCrossing)
+	}
+	return math.Max(0, degreeDays/math.Pi)
+}
@@ -0,0 +1,92 @@
+package cropgraph
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGDDMethods(t *testing.T) {
+	minimum := NewFloatColumn("Tmin", []float64{0, 10, 5})
+	maximum := NewFloatColumn("Tmax", []float64{20, 30, 5})
+	for _, test := range []struct {
+		method string
+		cutoff interface{}
+		want   []float64
+	}{
+		// the last day is constant at 5 °C, below the base
+		{GDDAverage, nil, []float64{0, 10, 0}},
+		{GDDTriangle, nil, []float64{2.5, 10, 0}},
+		{GDDSine, nil, []float64{10 / math.Pi, 10, 0}},
+		// temperatures above the cutoff count as the cutoff temperature
+		{GDDAverage, 20, []float64{0, 10, 0}},
+		{GDDTriangle, 20, []float64{2.5, 7.5, 0}},
+		{GDDSine, 20, []float64{10 / math.Pi, 10 - 10/math.Pi, 0}},
+	} {
+		parameters := map[string]interface{}{"method": test.method, "base": 10}
+		if test.cutoff != nil {
+			parameters["cutoff"] = test.cutoff
+		}
+		operation := OperationDefinition{Operation: "gdd", Name: "gdd", Parameters: parameters}
+		result, err := HandleColumnViewOperation(operation, []*Column{minimum, maximum}, OperationContext{})
+		if err != nil {
+			t.Errorf("%s cutoff %v: %v", test.method, test.cutoff, err)
+			continue
+		}
+		if values := resultValues(result); !equalValues(values, test.want) {
+			t.Errorf("%s cutoff %v: gdd = %v, want %v", test.method, test.cutoff, values, test.want)
+		}
+	}
+}
+
+func TestGDDMeanTemperature(t *testing.T) {
+	operation := OperationDefinition{Operation: "gdd", Name: "gdd", Parameters: map[string]interface{}{"base": 5}}
+	mean := NewFloatColumn("Tmean", []float64{15, 3})
+	mean.Unit = "°C"
+	result, err := HandleColumnViewOperation(operation, []*Column{mean}, OperationContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := resultValues(result); !equalValues(values, []float64{10, 0}) {
+		t.Errorf("gdd = %v, want [10 0]", values)
+	}
+	if result.Unit != "°C d" {
+		t.Errorf("unit = %q, want °C d", result.Unit)
+	}
+
+	// triangle and sine need the daily range
+	operation.Parameters["method"] = GDDTriangle
+	if _, err := HandleColumnViewOperation(operation, []*Column{mean}, OperationContext{}); err == nil {
+		t.Error("expected an error for the triangle method with a mean temperature column")
+	}
+}
+
+func TestGDDAccumulate(t *testing.T) {
+	missing := math.NaN()
+	// tuesday 2022-08-30 to sunday 2022-09-04
+	dates, _ := dailyColumns(time.Date(2022, 8, 30, 0, 0, 0, 0, time.UTC), make([]float64, 6))
+	mean := NewFloatColumn("Tmean", []float64{15, 15, 15, 10, 15, 25})
+	for _, test := range []struct {
+		name       string
+		parameters map[string]interface{}
+		want       []float64
+	}{
+		{"accumulate", map[string]interface{}{}, []float64{10, 20, 30, 35, 45, 65}},
+		// yaml decodes an unquoted date as time.Time, the days before the start are missing
+		{"start", map[string]interface{}{"start": time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)}, []float64{missing, missing, 10, 15, 25, 45}},
+		{"quoted start", map[string]interface{}{"start": "2022-09-01"}, []float64{missing, missing, 10, 15, 25, 45}},
+		{"reset", map[string]interface{}{"reset": "09-03"}, []float64{10, 20, 30, 35, 10, 30}},
+	} {
+		test.parameters["base"] = 5
+		test.parameters["accumulate"] = true
+		operation := OperationDefinition{Operation: "gdd", Name: "gdd", Parameters: test.parameters}
+		result, err := HandleColumnViewOperation(operation, []*Column{mean}, OperationContext{Dates: dates})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if values := resultValues(result); !equalValues(values, test.want) {
+			t.Errorf("%s: gdd = %v, want %v", test.name, values, test.want)
+		}
+	}
+}
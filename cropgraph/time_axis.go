@@ -0,0 +1,74 @@
+package cropgraph
+
+import (
+	"slices"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// layout of the dates on a time axis, echarts reads them as local time
+const timeAxisLayout = "2006-01-02 15:04:05"
+
+// a step between two dates larger than gapFactor times the usual step is drawn as a gap
+const gapFactor = 1.5
+
+// hasTimeAxis checks if the date column can be drawn on a time axis
+func hasTimeAxis(dateColumn *Column) bool {
+	return dateColumn != nil && dateColumn.Type == TimeColumn
+}
+
+// withTimeAxis sets a time x axis, echarts adapts the labels (days, months, years) to the zoom level
+func withTimeAxis() charts.GlobalOpts {
+	return charts.WithXAxisOpts(opts.XAxis{
+		Type: "time",
+	})
+}
+
+// usualStep returns the median step between consecutive valid dates of the column
+func usualStep(dateColumn *Column) time.Duration {
+	steps := []time.Duration{}
+	previous := -1
+	for row := 0; row < dateColumn.Len(); row++ {
+		if !dateColumn.Valid[row] {
+			continue
+		}
+		if previous >= 0 {
+			if step := dateColumn.Times[row].Sub(dateColumn.Times[previous]); step > 0 {
+				steps = append(steps, step)
+			}
+		}
+		previous = row
+	}
+	if len(steps) == 0 {
+		return 0
+	}
+	slices.Sort(steps)
+	return steps[len(steps)/2]
+}
+
+// timeValues returns [date, value] pairs of the rows with a valid date,
+// missing values are "-" and a "-" pair is inserted where the output skips dates, so echarts draws a gap
+func timeValues(dateColumn *Column, values *Column) [][]interface{} {
+	step := usualStep(dateColumn)
+	pairs := make([][]interface{}, 0, dateColumn.Len())
+	previous := -1
+	for row := 0; row < dateColumn.Len() && row < values.Len(); row++ {
+		if !dateColumn.Valid[row] {
+			continue
+		}
+		date := dateColumn.Times[row]
+		if previous >= 0 && step > 0 && float64(date.Sub(dateColumn.Times[previous])) > gapFactor*float64(step) {
+			gap := dateColumn.Times[previous].Add(step)
+			pairs = append(pairs, []interface{}{gap.Format(timeAxisLayout), "-"})
+		}
+		value := values.Value(row)
+		if value == nil {
+			value = "-"
+		}
+		pairs = append(pairs, []interface{}{date.Format(timeAxisLayout), value})
+		previous = row
+	}
+	return pairs
+}
@@ -0,0 +1,191 @@
+package cropgraph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unitDefinition relates a unit to the base unit of its dimension: base = value*factor + offset
+type unitDefinition struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+// built-in unit registry, units per area are based on kg/ha, water contents on m3/m3
+var unitRegistry = map[string]unitDefinition{
+	// length and water depth
+	"m":    {"length", 1, 0},
+	"dm":   {"length", 0.1, 0},
+	"cm":   {"length", 0.01, 0},
+	"mm":   {"length", 0.001, 0},
+	"km":   {"length", 1000, 0},
+	"l/m2": {"length", 0.001, 0},
+	// area
+	"m2":  {"area", 1, 0},
+	"ha":  {"area", 10000, 0},
+	"km2": {"area", 1e6, 0},
+	// mass
+	"kg": {"mass", 1, 0},
+	"g":  {"mass", 0.001, 0},
+	"mg": {"mass", 1e-6, 0},
+	"t":  {"mass", 1000, 0},
+	"dt": {"mass", 100, 0},
+	// mass per area
+	"kg/ha":  {"mass/area", 1, 0},
+	"t/ha":   {"mass/area", 1000, 0},
+	"Mg/ha":  {"mass/area", 1000, 0},
+	"dt/ha":  {"mass/area", 100, 0},
+	"g/m2":   {"mass/area", 10, 0},
+	"kg/m2":  {"mass/area", 10000, 0},
+	"mg/m2":  {"mass/area", 0.01, 0},
+	"lb/ac":  {"mass/area", 1.120851, 0},
+	"g/ha":   {"mass/area", 0.001, 0},
+	"kg/km2": {"mass/area", 0.01, 0},
+	// fractions and water contents
+	"1":       {"fraction", 1, 0},
+	"m3/m3":   {"fraction", 1, 0},
+	"cm3/cm3": {"fraction", 1, 0},
+	"mm/mm":   {"fraction", 1, 0},
+	"%":       {"fraction", 0.01, 0},
+	"vol%":    {"fraction", 0.01, 0},
+	// temperature
+	"°C": {"temperature", 1, 0},
+	"K":  {"temperature", 1, -273.15},
+	"°F": {"temperature", 5.0 / 9.0, -32 * 5.0 / 9.0},
+	// time
+	"s":   {"time", 1, 0},
+	"min": {"time", 60, 0},
+	"h":   {"time", 3600, 0},
+	"d":   {"time", 86400, 0},
+}
+
+// parsedUnit is a unit of the registry, with an optional substance, e.g. kg N/ha
+type parsedUnit struct {
+	unitDefinition
+	substance string
+}
+
+// parseUnit looks up the unit in the registry
+// superscripts are written as digits (m² as m2) and a substance may follow the numerator (kg N/ha, g N/m2)
+func parseUnit(unit string) (parsedUnit, error) {
+	normalized := strings.NewReplacer("²", "2", "³", "3", "deg C", "°C", "degC", "°C", "deg F", "°F").Replace(strings.TrimSpace(unit))
+	substance := ""
+	if numerator, denominator, ok := strings.Cut(normalized, "/"); ok {
+		if fields := strings.Fields(numerator); len(fields) == 2 {
+			substance = fields[1]
+			normalized = fields[0] + "/" + strings.TrimSpace(denominator)
+		}
+	}
+	definition, ok := unitRegistry[normalized]
+	if !ok {
+		return parsedUnit{}, fmt.Errorf("unknown unit %q", unit)
+	}
+	return parsedUnit{unitDefinition: definition, substance: substance}, nil
+}
+
+// unitConversion returns factor and offset to convert values from one unit to another: to = from*factor + offset
+func unitConversion(from, to string) (float64, float64, error) {
+	fromUnit, err := parseUnit(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	toUnit, err := parseUnit(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if fromUnit.dimension != toUnit.dimension || fromUnit.substance != toUnit.substance {
+		return 0, 0, fmt.Errorf("can not convert %s to %s", from, to)
+	}
+	factor := fromUnit.factor / toUnit.factor
+	offset := (fromUnit.offset - toUnit.offset) / toUnit.factor
+	return factor, offset, nil
+}
+
+// volumeFraction returns the factor from the unit of the column to a volume fraction, 1 if the column has no unit
+func volumeFraction(operationDefinition OperationDefinition, column *Column) (float64, error) {
+	if column.Unit == "" {
+		return 1, nil
+	}
+	factor, _, err := unitConversion(column.Unit, "1")
+	if err != nil {
+		return 0, fmt.Errorf("operation %s: column %s is no volumetric content: %w", operationDefinition.Name, column.Name, err)
+	}
+	return factor, nil
+}
+
+// seriesName appends the unit to the name of a series, e.g. "Yield [kg/ha]"
+func seriesName(name, unit string) string {
+	if unit == "" || strings.HasSuffix(name, "["+unit+"]") {
+		return name
+	}
+	return name + " [" + unit + "]"
+}
+
+// groupByUnit groups the indices of the columns by unit, in the order of the first column of each unit,
+// units of the registry, which can be converted into each other (e.g. kg/ha and t/ha), are one group
+// columns without unit are grouped with the columns of the only unit, if there is a single unit
+func groupByUnit(columns []*Column) [][]int {
+	keys := []string{}
+	groups := map[string][]int{}
+	for i, column := range columns {
+		key := unitGroupKey(column.Unit)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	if len(keys) == 1 || (len(keys) == 2 && groups[""] != nil) {
+		return [][]int{allIndices(len(columns))}
+	}
+	grouped := make([][]int, 0, len(keys))
+	for _, key := range keys {
+		grouped = append(grouped, groups[key])
+	}
+	return grouped
+}
+
+// unitGroupKey returns the dimension and substance of a unit of the registry, or the unit itself
+func unitGroupKey(unit string) string {
+	if parsed, err := parseUnit(unit); err == nil && unit != "" {
+		return parsed.dimension + " " + parsed.substance
+	}
+	return unit
+}
+
+// groupUnits lists the unit of each group
+func groupUnits(columns []*Column, groups [][]int) []string {
+	units := make([]string, 0, len(groups))
+	for _, group := range groups {
+		unit := columns[group[0]].Unit
+		if unit == "" {
+			unit = "(none)"
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+// commonUnit returns the unit of the columns, empty if the columns have different units
+func commonUnit(columns []*Column) string {
+	unit := ""
+	for _, column := range columns {
+		if column.Unit == "" {
+			continue
+		}
+		if unit != "" && column.Unit != unit {
+			return ""
+		}
+		unit = column.Unit
+	}
+	return unit
+}
+
+// allIndices returns the indices 0 to n-1
+func allIndices(n int) []int {
+	rows := make([]int, n)
+	for i := range rows {
+		rows[i] = i
+	}
+	return rows
+}
@@ -0,0 +1,54 @@
+package cropgraph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupByUnit(t *testing.T) {
+	column := func(unit string) *Column {
+		column := NewFloatColumn("c", []float64{1})
+		column.Unit = unit
+		return column
+	}
+	for _, test := range []struct {
+		units []string
+		want  [][]int
+	}{
+		{[]string{"kg/ha", "kg/ha"}, [][]int{{0, 1}}},
+		{[]string{"kg/ha", "t/ha", "mm", "dm"}, [][]int{{0, 1}, {2, 3}}},
+		{[]string{"kg N/ha", "kg/ha"}, [][]int{{0}, {1}}},
+		{[]string{"mm", ""}, [][]int{{0, 1}}},
+		{[]string{"°C", "mm", "K"}, [][]int{{0, 2}, {1}}},
+		{[]string{"leaves", "leaves", "plants"}, [][]int{{0, 1}, {2}}},
+	} {
+		columns := make([]*Column, len(test.units))
+		for i, unit := range test.units {
+			columns[i] = column(unit)
+		}
+		if got := groupByUnit(columns); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: found %v, want %v", test.units, got, test.want)
+		}
+	}
+}
+
+func TestValidateConvert(t *testing.T) {
+	catalog := ColumnCatalog{"Yield": {Unit: "kg/ha"}}
+	for _, test := range []struct {
+		operation OperationDefinition
+		ok        bool
+	}{
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"Yield"}, Parameters: map[string]interface{}{"to": "t/ha"}}, true},
+		// the unit of the catalog is the default of from
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"Yield"}, Parameters: map[string]interface{}{"to": "mm"}}, false},
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"Yield"}, Parameters: map[string]interface{}{"from": "g/m2", "to": "t/ha"}}, true},
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"LAI"}, Parameters: map[string]interface{}{"to": "t/ha"}}, true},
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"Yield"}, Parameters: map[string]interface{}{"to": "t/ha"}, Multiply: 1}, true},
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"Yield"}, Parameters: map[string]interface{}{"to": "t/ha"}, Multiply: 0.5}, false},
+		{OperationDefinition{Operation: "convert", Name: "y", Columns: []string{"Yield"}}, false},
+	} {
+		if err := validateOperation(test.operation, catalog); (err == nil) != test.ok {
+			t.Errorf("%+v: found %v", test.operation, err)
+		}
+	}
+}